@@ -1,8 +1,17 @@
 module go.jpap.org/godoc-readme-gen
 
-go 1.16
+go 1.22.0
 
 require (
 	github.com/alecthomas/chroma v0.9.2
-	golang.org/x/tools v0.1.5
+	github.com/google/licensecheck v0.3.1
+	golang.org/x/mod v0.22.0
+	golang.org/x/tools v0.27.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/danwakefield/fnmatch v0.0.0-20160403171240-cbb64ac3d964 // indirect
+	github.com/dlclark/regexp2 v1.4.0 // indirect
+	golang.org/x/sync v0.9.0 // indirect
 )
@@ -9,88 +9,100 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
-)
+	"strings"
 
-const defaultTemplateFile = ".README.template.md"
+	"go.jpap.org/godoc-readme-gen/readmegen"
+)
 
 var (
-	flagForce         = flag.Bool("f", false, "Run even if README.md exists, overwriting original")
-	flagPrintTemplate = flag.Bool("print-template", false, "Print the built in template to stdout and exit")
-	flagTemplate      = flag.String("template", defaultTemplateFile, "Template to use, or builtin if does not exist")
-	flagTitle         = flag.String("title", "", "Title of the README.md")
-	flagDefs          defFlag
+	flagForce          = flag.Bool("f", false, "Run even if README.md exists, overwriting original")
+	flagCheck          = flag.Bool("check", false, "Check that README.md is up to date, printing a diff and failing if not, without writing it")
+	flagPrintTemplate  = flag.Bool("print-template", false, "Print the built in template to stdout and exit")
+	flagTemplate       = flag.String("template", "", "Template to use (default "+readmegen.DefaultTemplateFile+", or builtin if it does not exist), or empty for the builtin template")
+	flagConfig         = flag.String("config", readmegen.DefaultConfigFile, "Name of the config file, found in the package directory or a parent up to the module root, or empty for none")
+	flagOutput         = flag.String("o", "", "Path of the README, relative to the package directory, or - for stdout (default README.md)")
+	flagTitle          = flag.String("title", "", "Title of the README.md")
+	flagTolerateErrors = flag.Bool("tolerate-errors", false, "Render docs from the parsed source of packages that fail to load, such as those that fail to type check")
+	flagMarkers        = flag.Bool("markers", false, "Only replace the sections of an existing README.md between <!-- godoc-readme-gen:start name --> and <!-- godoc-readme-gen:end name --> markers with the named template block")
+	flagIndex          = flag.Bool("index", false, "Generate an index README.md in the package directory, or working directory, that links to the README of each package")
+	flagBadges         = flag.String("badges", strings.Join(readmegen.DefaultBadges, ","), "Comma-separated names of the badges beside the title, in order: godoc, goreportcard, ci, license, coverage, release, goversion")
+	flagTOCDepth       = flag.Int("toc-depth", 0, "Number of heading levels listed by the table of contents, .TOC and {{toc}}, including the top level, or 0 for all")
+	flagHeadingOffset  = flag.Int("heading-offset", 0, "Number of levels added to the headings of doc comments, which are otherwise ## headings")
+	flagNestHeadings   = flag.Bool("nest-headings", false, "Nest the headings of doc comments one level below the enclosing heading of the template")
+	flagLinkIdents     = flag.Bool("link-identifiers", false, "Link the exported identifiers of the package in doc comments to their sections of the API")
+	flagRawMarkdown    = flag.Bool("raw-markdown", false, "Pass the text of doc comments through as-is, rather than escaping Markdown metacharacters, for comments written in Markdown")
+	flagDocBaseURL     = flag.String("doc-base-url", readmegen.DefaultDocBaseURL, "Base URL of the doc server that [Name] doc links refer to")
+	flagDefs           readmegen.DefFlag
 )
 
-func getOrCreateReadmeFile(dir string) (*os.File, error) {
-	nm := filepath.Join(dir, "README.md")
-	if !*flagForce {
-		_, err := os.Stat(nm)
-		if err == nil {
-			return nil, fmt.Errorf("README.md already exists at %s. Use -f to overwrite", dir)
-		} else if !os.IsNotExist(err) {
-			return nil, err
-		}
-	}
-	return os.Create(nm)
-}
-
 func main() {
 	log.SetFlags(0)
 
-	flag.Var(&flagDefs, "def", "Template define having the form: name=value")
+	flag.Var(&flagDefs, "def", "Template define having the form: name=value, where a dotted name such as links.docs sets a key of a nested map")
+	flag.Var(flagDefs.JSON(), "def-json", "Template define having the form: name=json, where the value is decoded from JSON")
+	flag.Var(flagDefs.File(), "def-file", "Template define having the form: name=path, where the value is decoded from a JSON or YAML file")
 	flag.Usage = func() {
-		log.Printf("Usage of %s: %s [directory]", os.Args[0], os.Args[0])
+		log.Printf("Usage of %s: %s [directory | packages]", os.Args[0], os.Args[0])
 		flag.PrintDefaults()
 	}
 	flag.Parse()
 
 	if *flagPrintTemplate {
-		fmt.Print(templateString)
+		fmt.Print(readmegen.BuiltinTemplate())
 		return
 	}
 
-	dir := "."
-
-	if args := flag.Args(); len(args) > 1 {
-		log.Fatalln("Too many arguments: ", args[1:])
-		flag.Usage()
-	} else if len(args) == 1 {
-		dir = args[0]
+	opts := readmegen.Options{
+		Defs:           flagDefs,
+		DocBaseURL:     *flagDocBaseURL,
+		ConfigFile:     *flagConfig,
+		Force:          *flagForce,
+		Check:          *flagCheck,
+		Index:          *flagIndex,
+		Markers:        *flagMarkers,
+		TolerateErrors: *flagTolerateErrors,
+		Stdout:         os.Stdout,
+		Diff:           os.Stdout,
+		Logf:           log.Printf,
 	}
+	// Flags that are not given are left to the config file, even if given
+	// their default values.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			opts.Title = flagTitle
+		case "template":
+			opts.Template = flagTemplate
+		case "o":
+			opts.Output = flagOutput
+		case "badges":
+			opts.Badges = badges(*flagBadges)
+		case "toc-depth":
+			opts.TOCDepth = flagTOCDepth
+		case "heading-offset":
+			opts.HeadingOffset = flagHeadingOffset
+		case "nest-headings":
+			opts.NestHeadings = flagNestHeadings
+		case "link-identifiers":
+			opts.LinkIdentifiers = flagLinkIdents
+		case "raw-markdown":
+			opts.RawMarkdown = flagRawMarkdown
+		}
+	})
 
-	dir, err := filepath.Abs(dir)
-	if err != nil {
+	g := readmegen.New(opts)
+	if err := g.Generate(flag.Args()...); err != nil {
 		log.Fatalln(err)
 	}
+}
 
-	doc, err := NewDoc(dir)
-	if err != nil {
-		log.Fatalf("Failed to load package in dir %q: %v\n", dir, err)
-	}
-
-	f, err := getOrCreateReadmeFile(dir)
-	if err != nil {
-		log.Fatalf("Failed to create README.md for %q: %v\n", dir, err)
-	}
-
-	// Convert the doc to a map, so we can add additional fields
-	docm := doc.Map()
-	for _, d := range flagDefs {
-		// Lowercase define
-		docm[d.Name] = d.Value
-		// Uppercase define
-		d = d.UpperClone()
-		docm[d.Name] = d.Value
-	}
-
-	// Execute the template
-	tmpl, err := getTemplate(dir)
-	if err != nil {
-		log.Fatalf("Failed to load template: %v\n", err)
-	}
-
-	if err = tmpl.Execute(f, docm); err != nil {
-		log.Fatalf("Failed to execute template: %v\n", err)
+// badges returns the names of the badges in the comma-separated list s.
+func badges(s string) []string {
+	names := []string{}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
 	}
+	return names
 }
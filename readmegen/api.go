@@ -0,0 +1,195 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/token"
+	"strings"
+)
+
+// API is the exported API of a package, in the same order as godoc.
+type API struct {
+	Consts []Value
+	Vars   []Value
+	Funcs  []Func
+	Types  []Type
+}
+
+// Value is a const or var declaration, that may declare more than one name.
+type Value struct {
+	Names []string
+	Decl  string // formatted declaration
+	Doc   string // rendered Markdown documentation
+}
+
+// Func is a function or method declaration.
+type Func struct {
+	Name     string
+	Recv     string // receiver type, e.g. "*T", for methods
+	Decl     string // formatted signature
+	Doc      string // rendered Markdown documentation
+	Anchor   string // anchor of its heading in the builtin template
+	Examples []Example
+}
+
+// Type is a type declaration, together with its associated values,
+// constructors and methods.
+type Type struct {
+	Name     string
+	Decl     string // formatted declaration
+	Doc      string // rendered Markdown documentation
+	Anchor   string // anchor of its heading in the builtin template
+	Consts   []Value
+	Vars     []Value
+	Funcs    []Func // constructors, that return a value of this type
+	Methods  []Func
+	Examples []Example
+}
+
+// Empty returns true if the package has no exported API.
+func (a API) Empty() bool {
+	return len(a.Consts) == 0 && len(a.Vars) == 0 &&
+		len(a.Funcs) == 0 && len(a.Types) == 0
+}
+
+// markDocs returns a copy of the receiver with the docs of each declaration
+// marked, as per markDoc.
+func (a API) markDocs() API {
+	return API{
+		Consts: markValueDocs(a.Consts),
+		Vars:   markValueDocs(a.Vars),
+		Funcs:  markFuncDocs(a.Funcs),
+		Types:  markTypeDocs(a.Types),
+	}
+}
+
+func markValueDocs(vs []Value) []Value {
+	var out []Value
+	for _, v := range vs {
+		v.Doc = markDoc(v.Doc)
+		out = append(out, v)
+	}
+	return out
+}
+
+func markFuncDocs(fs []Func) []Func {
+	var out []Func
+	for _, f := range fs {
+		f.Doc = markDoc(f.Doc)
+		out = append(out, f)
+	}
+	return out
+}
+
+func markTypeDocs(ts []Type) []Type {
+	var out []Type
+	for _, t := range ts {
+		t.Doc = markDoc(t.Doc)
+		t.Consts = markValueDocs(t.Consts)
+		t.Vars = markValueDocs(t.Vars)
+		t.Funcs = markFuncDocs(t.Funcs)
+		t.Methods = markFuncDocs(t.Methods)
+		out = append(out, t)
+	}
+	return out
+}
+
+// newAPI extracts the exported API from pkg, rendering doc comments with r.
+// The fset must be the one used to parse the package files, so that
+// declarations keep their original layout.
+func newAPI(fset *token.FileSet, r *docRenderer, pkg *doc.Package) API {
+	return API{
+		Consts: newValues(fset, r, pkg.Consts),
+		Vars:   newValues(fset, r, pkg.Vars),
+		Funcs:  newFuncs(fset, r, "", pkg.Funcs),
+		Types:  newTypes(fset, r, pkg.Types),
+	}
+}
+
+func newValues(fset *token.FileSet, r *docRenderer, vs []*doc.Value) []Value {
+	var out []Value
+	for _, v := range vs {
+		// The doc comment is rendered separately, so drop it from the decl.
+		decl := *v.Decl
+		decl.Doc = nil
+		out = append(out, Value{
+			Names: v.Names,
+			Decl:  formatDecl(fset, &decl),
+			Doc:   r.apiDocString(v.Doc, ""),
+		})
+	}
+	return out
+}
+
+// newFuncs returns the funcs fs, which are methods when typeName is not "".
+func newFuncs(fset *token.FileSet, r *docRenderer, typeName string, fs []*doc.Func) []Func {
+	var out []Func
+	for _, f := range fs {
+		decl := *f.Decl
+		decl.Doc = nil
+		decl.Body = nil
+		symbol := f.Name
+		if f.Recv != "" {
+			symbol = typeName + "." + f.Name
+		}
+		out = append(out, Func{
+			Name:     f.Name,
+			Recv:     f.Recv,
+			Decl:     formatDecl(fset, &decl),
+			Doc:      r.apiDocString(f.Doc, symbol),
+			Anchor:   funcAnchor(f),
+			Examples: renderExamples(fset, f.Examples, symbol),
+		})
+	}
+	return out
+}
+
+func newTypes(fset *token.FileSet, r *docRenderer, ts []*doc.Type) []Type {
+	var out []Type
+	for _, t := range ts {
+		decl := *t.Decl
+		decl.Doc = nil
+		out = append(out, Type{
+			Name:     t.Name,
+			Decl:     formatDecl(fset, &decl),
+			Doc:      r.apiDocString(t.Doc, t.Name),
+			Anchor:   typeAnchor(t.Name),
+			Consts:   newValues(fset, r, t.Consts),
+			Vars:     newValues(fset, r, t.Vars),
+			Funcs:    newFuncs(fset, r, t.Name, t.Funcs),
+			Methods:  newFuncs(fset, r, t.Name, t.Methods),
+			Examples: renderExamples(fset, t.Examples, t.Name),
+		})
+	}
+	return out
+}
+
+// renderExamples renders the examples exs of symbol.
+func renderExamples(fset *token.FileSet, exs []*doc.Example, symbol string) []Example {
+	var out []Example
+	for _, ex := range exs {
+		out = append(out, renderExample(fset, ex, symbol))
+	}
+	return out
+}
+
+// apiDocString renders the doc comment of the declaration of symbol as
+// Markdown, without surrounding whitespace.  The symbol is not linked to
+// itself.
+func (r *docRenderer) apiDocString(text, symbol string) string {
+	return strings.TrimSpace(r.symbolDocString(text, symbol))
+}
+
+// formatDecl returns the gofmt'd source of decl.
+func formatDecl(fset *token.FileSet, decl ast.Decl) string {
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, decl); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,196 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Badge is a badge image, typically shown beside the title of the README.
+type Badge struct {
+	Name  string // name of the badge in the registry, e.g. "godoc"
+	Alt   string // alternative text of the image
+	Image string // URL of the image
+	Link  string // URL of the page the badge links to, or "" for none
+}
+
+// Markdown returns the Markdown of the badge image, along with its link.
+func (b Badge) Markdown() string {
+	img := "![" + b.Alt + "](" + b.Image + ")"
+	if b.Link == "" {
+		return img
+	}
+	return "[" + img + "](" + b.Link + ")"
+}
+
+// A BadgeFunc returns the badges of the package documented by d, or none if the
+// badge does not apply to the package.
+type BadgeFunc func(d *Doc) []Badge
+
+// DefaultBadges are the names of the badges rendered when the Badges option is
+// nil.
+var DefaultBadges = []string{"godoc", "ci"}
+
+// badgeFuncs are the registered badges, by name.
+var badgeFuncs = map[string]BadgeFunc{
+	"godoc":        godocBadge,
+	"goreportcard": goReportCardBadge,
+	"ci":           ciBadges,
+	"license":      licenseBadge,
+	"coverage":     coverageBadge,
+	"release":      releaseBadge,
+	"goversion":    goVersionBadge,
+}
+
+// RegisterBadge registers the badge fn by name, so that it may be given in the
+// Badges option, replacing any badge of the same name.
+func RegisterBadge(name string, fn BadgeFunc) {
+	badgeFuncs[name] = fn
+}
+
+// newBadges returns the badges of d with the given names, in order.
+func newBadges(d *Doc, names []string) ([]Badge, error) {
+	var badges []Badge
+	for _, name := range names {
+		fn, ok := badgeFuncs[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown badge %q", name)
+		}
+		for _, b := range fn(d) {
+			b.Name = name
+			badges = append(badges, b)
+		}
+	}
+	return badges, nil
+}
+
+// modulePath returns the path of the module of d, else its import path.
+func modulePath(d *Doc) string {
+	if d.Module != nil {
+		return d.Module.Path
+	}
+	return d.ImportPath
+}
+
+func godocBadge(d *Doc) []Badge {
+	if !d.IsLibrary {
+		return nil
+	}
+	return []Badge{{
+		Alt:   "GoDoc",
+		Image: "https://pkg.go.dev/badge/" + d.ImportPath + ".svg",
+		Link:  "https://pkg.go.dev/" + d.ImportPath,
+	}}
+}
+
+func goReportCardBadge(d *Doc) []Badge {
+	return []Badge{{
+		Alt:   "Go Report Card",
+		Image: "https://goreportcard.com/badge/" + modulePath(d),
+		Link:  "https://goreportcard.com/report/" + modulePath(d),
+	}}
+}
+
+func ciBadges(d *Doc) []Badge {
+	var badges []Badge
+	for _, ci := range d.CI {
+		if ci.BadgeURL != "" {
+			badges = append(badges, Badge{
+				Alt:   ci.Name,
+				Image: ci.BadgeURL,
+				Link:  ci.LinkURL,
+			})
+		}
+	}
+	return badges
+}
+
+func licenseBadge(d *Doc) []Badge {
+	for _, l := range d.Licenses {
+		if l.SPDX != "" {
+			return []Badge{{
+				Alt:   "License",
+				Image: "https://img.shields.io/badge/license-" + shieldsEscape(l.SPDX) + "-blue",
+				Link:  l.Path,
+			}}
+		}
+	}
+
+	// Otherwise, the forge may know the license.
+	r := d.Repo
+	if r == nil || (r.Forge != "github" && r.Forge != "gitlab") {
+		return nil
+	}
+	return []Badge{{
+		Alt:   "License",
+		Image: "https://img.shields.io/" + r.Forge + "/license/" + shieldsRepo(r),
+		Link:  r.WebURL,
+	}}
+}
+
+func coverageBadge(d *Doc) []Badge {
+	r := d.Repo
+	if r == nil {
+		return nil
+	}
+	service := map[string]string{"github": "gh", "gitlab": "gl", "bitbucket": "bb"}[r.Forge]
+	if service == "" {
+		return nil
+	}
+	link := "https://codecov.io/" + path.Join(service, r.Owner, r.Name)
+	return []Badge{{
+		Alt:   "Coverage",
+		Image: link + "/branch/" + badgeBranch(r) + "/graph/badge.svg",
+		Link:  link,
+	}}
+}
+
+func releaseBadge(d *Doc) []Badge {
+	r := d.Repo
+	if r == nil {
+		return nil
+	}
+	var tags string
+	switch r.Forge {
+	case "github":
+		tags = r.WebURL + "/tags"
+	case "gitlab":
+		tags = r.WebURL + "/-/tags"
+	default:
+		return nil
+	}
+	return []Badge{{
+		Alt:   "Release",
+		Image: "https://img.shields.io/" + r.Forge + "/v/tag/" + shieldsRepo(r) + "?sort=semver",
+		Link:  tags,
+	}}
+}
+
+func goVersionBadge(d *Doc) []Badge {
+	if d.Module == nil || d.Module.GoVersion == "" {
+		return nil
+	}
+	return []Badge{{
+		Alt:   "Go Version",
+		Image: "https://img.shields.io/badge/go-" + shieldsEscape(d.Module.GoVersion) + "-blue",
+	}}
+}
+
+// shieldsRepo returns the repository r as a path of a shields.io badge.
+func shieldsRepo(r *Repo) string {
+	if r.Forge == "gitlab" {
+		// GitLab projects may be in subgroups, so are given as a single element.
+		return url.PathEscape(r.Owner + "/" + r.Name)
+	}
+	return r.Owner + "/" + r.Name
+}
+
+// shieldsEscape escapes s for use as the text of a static shields.io badge.
+func shieldsEscape(s string) string {
+	s = strings.NewReplacer("-", "--", "_", "__").Replace(s)
+	return url.PathEscape(s)
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CI is a continuous integration pipeline configured for a package.
+type CI struct {
+	System   string // CI system, e.g. "github-actions"
+	Name     string // name of the workflow or pipeline
+	BadgeURL string // image of the build status badge, or "" if unknown
+	LinkURL  string // web page of the build status, or "" if unknown
+}
+
+// A ciSystem is a CI system that is detected from its config files.
+type ciSystem struct {
+	system  string
+	name    string   // name of its pipelines, unless named by the config
+	configs []string // globs of config files, relative to the searched dir
+	named   bool     // true when each config has a top-level "name:"
+
+	// badge returns the badge and its link for the config file, or "" if the
+	// repo is not supported.
+	badge func(r *Repo, file string) (badgeURL, linkURL string)
+}
+
+// ciSystems are the detected CI systems, in order of the badges.
+var ciSystems = []ciSystem{
+	{
+		system:  "github-actions",
+		name:    "GitHub Actions",
+		configs: []string{".github/workflows/*.yml", ".github/workflows/*.yaml"},
+		named:   true,
+		badge: func(r *Repo, file string) (string, string) {
+			if r.Forge != "github" {
+				return "", ""
+			}
+			link := r.WebURL + "/actions/workflows/" + file
+			return link + "/badge.svg", link
+		},
+	},
+	{
+		system:  "gitlab-ci",
+		name:    "GitLab CI",
+		configs: []string{".gitlab-ci.yml"},
+		badge: func(r *Repo, file string) (string, string) {
+			if r.Forge != "gitlab" {
+				return "", ""
+			}
+			return r.WebURL + "/badges/" + badgeBranch(r) + "/pipeline.svg",
+				r.WebURL + "/-/commits/" + badgeBranch(r)
+		},
+	},
+	{
+		system:  "circleci",
+		name:    "CircleCI",
+		configs: []string{".circleci/config.yml"},
+		badge: func(r *Repo, file string) (string, string) {
+			vcs := map[string]string{"github": "gh", "bitbucket": "bb"}[r.Forge]
+			if vcs == "" {
+				return "", ""
+			}
+			link := "https://circleci.com/" + path.Join(vcs, r.Owner, r.Name)
+			return link + ".svg?style=shield", link
+		},
+	},
+	{
+		system:  "drone",
+		name:    "Drone",
+		configs: []string{".drone.yml"},
+		badge: func(r *Repo, file string) (string, string) {
+			return "https://cloud.drone.io/api/badges/" + path.Join(r.Owner, r.Name) + "/status.svg",
+				"https://cloud.drone.io/" + path.Join(r.Owner, r.Name)
+		},
+	},
+	{
+		system:  "buildkite",
+		name:    "Buildkite",
+		configs: []string{".buildkite/pipeline.yml", ".buildkite/pipeline.yaml"},
+		badge: func(r *Repo, file string) (string, string) {
+			return "", "" // the badge URL holds a secret of the pipeline
+		},
+	},
+	{
+		system:  "travis",
+		name:    "Travis CI",
+		configs: []string{".travis.yml"},
+		badge: func(r *Repo, file string) (string, string) {
+			link := "https://app.travis-ci.com/" + path.Join(r.Owner, r.Name)
+			return link + ".svg?branch=" + badgeBranch(r), link
+		},
+	},
+}
+
+// badgeBranch returns the branch whose build status is shown by badges.
+func badgeBranch(r *Repo) string {
+	if r.DefaultBranch != "" {
+		return r.DefaultBranch
+	}
+	return "master"
+}
+
+// detectCI returns the CI pipelines configured in the package dir, or in the
+// root of its module or repo.  The badges of each are of the repo r, if known.
+func detectCI(dir string, mod *Module, r *Repo) []CI {
+	dirs := []string{dir}
+	if mod != nil && mod.Dir != "" {
+		dirs = append(dirs, mod.Dir)
+	}
+	if r != nil && r.Dir != "" {
+		dirs = append(dirs, r.Dir)
+	}
+
+	var cis []CI
+	seen := make(map[string]bool)
+	for _, sys := range ciSystems {
+		for _, dir := range dirs {
+			for _, glob := range sys.configs {
+				files, _ := filepath.Glob(filepath.Join(dir, glob))
+				for _, file := range files {
+					if seen[file] {
+						continue
+					}
+					seen[file] = true
+
+					ci := CI{System: sys.system, Name: sys.name}
+					if sys.named {
+						if name := yamlName(file); name != "" {
+							ci.Name = name
+						} else {
+							ci.Name = filepath.Base(file)
+						}
+					}
+					if r != nil {
+						ci.BadgeURL, ci.LinkURL = sys.badge(r, filepath.Base(file))
+					}
+					cis = append(cis, ci)
+				}
+			}
+		}
+	}
+	return cis
+}
+
+// yamlName returns the value of the top-level "name:" key of the YAML file, or
+// "" if there is none.
+func yamlName(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "name:") {
+			continue
+		}
+		v := strings.TrimSpace(strings.TrimPrefix(line, "name:"))
+		if strings.HasPrefix(v, `"`) {
+			if uq, err := strconv.Unquote(v); err == nil {
+				return uq
+			}
+		} else if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		return strings.Trim(v, "'")
+	}
+	return ""
+}
@@ -0,0 +1,218 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is the name of the project configuration file used by the
+// command, found in the package directory or one of its parents up to the
+// module root.
+const DefaultConfigFile = ".godoc-readme-gen.yaml"
+
+// Config is the configuration of a project, read from a YAML config file, that
+// provides the options not otherwise given to the Generator.
+type Config struct {
+	// Title of the README.
+	Title *string `yaml:"title"`
+
+	// Template is the path of the template file, relative to the config file,
+	// or "" for the builtin template.
+	Template *string `yaml:"template"`
+
+	// Defs are additional template variables, by name, that may be structured
+	// data such as lists and maps.
+	Defs map[string]interface{} `yaml:"defs"`
+
+	// Badges are the names of the badges beside the title, in order.
+	Badges []string `yaml:"badges"`
+
+	// Output is the path of the README, relative to the package directory.
+	Output *string `yaml:"output"`
+
+	// TOCDepth is the number of heading levels listed by the table of contents,
+	// including the top level.
+	TOCDepth *int `yaml:"tocDepth"`
+
+	// HeadingOffset is added to the level of the headings of doc comments.
+	HeadingOffset *int `yaml:"headingOffset"`
+
+	// NestHeadings nests the headings of doc comments below the enclosing
+	// heading of the template.
+	NestHeadings *bool `yaml:"nestHeadings"`
+
+	// RawMarkdown passes the text of doc comments through as-is.
+	RawMarkdown *bool `yaml:"rawMarkdown"`
+
+	// LinkIdentifiers links the exported identifiers of the package in doc
+	// comments to their API sections.
+	LinkIdentifiers *bool `yaml:"linkIdentifiers"`
+
+	// Packages overrides the above for the packages in the directories given
+	// by their slash-separated path, relative to the config file.
+	Packages map[string]*Config `yaml:"packages"`
+}
+
+// readConfig reads the config file at path.
+func readConfig(path string) (*Config, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	var c Config
+	if err := dec.Decode(&c); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := checkDefNames(c.Defs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for rel, pc := range c.Packages {
+		if pc == nil {
+			continue
+		}
+		if len(pc.Packages) > 0 {
+			return nil, fmt.Errorf("failed to parse %s: packages of %q cannot have packages", path, rel)
+		}
+		if err := checkDefNames(pc.Defs); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: packages of %q: %w", path, rel, err)
+		}
+	}
+	return &c, nil
+}
+
+// checkDefNames returns an error if one of the names of the defs m is invalid,
+// as per checkDefName.
+func checkDefNames(m map[string]interface{}) error {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := checkDefName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findConfig returns the path of the config file named name in dir, or the
+// nearest of its parents up to and including modDir, or "" if there is none.
+// If dir is not within modDir, only dir is searched.
+func findConfig(name, dir, modDir string) (string, error) {
+	if rel, err := filepath.Rel(modDir, dir); modDir == "" || err != nil || strings.HasPrefix(rel, "..") {
+		modDir = dir
+	}
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if dir == modDir {
+			return "", nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// withConfig returns a Generator for the package in dir, within the module
+// root modDir, if any, whose options are those of the receiver merged with the
+// config file of the package.  Options given to the receiver take precedence
+// over the config file.
+func (g *Generator) withConfig(dir, modDir string) (*Generator, error) {
+	if g.opts.ConfigFile == "" {
+		return g, nil
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	path, err := findConfig(g.opts.ConfigFile, dir, modDir)
+	if err != nil || path == "" {
+		return g, err
+	}
+	c, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := g.opts
+	cdir := filepath.Dir(path)
+	rel, err := filepath.Rel(cdir, dir)
+	if err != nil {
+		return nil, err
+	}
+	// The package overrides come first, so that they take precedence.
+	for _, c := range []*Config{c.Packages[filepath.ToSlash(rel)], c} {
+		if c == nil {
+			continue
+		}
+		if opts.Title == nil {
+			opts.Title = c.Title
+		}
+		if opts.Template == nil && c.Template != nil {
+			path := *c.Template
+			if path != "" && !filepath.IsAbs(path) {
+				path = filepath.Join(cdir, filepath.FromSlash(path))
+			}
+			opts.Template = &path
+		}
+		if opts.Badges == nil {
+			opts.Badges = c.Badges
+		}
+		if opts.Output == nil {
+			opts.Output = c.Output
+		}
+		if opts.TOCDepth == nil {
+			opts.TOCDepth = c.TOCDepth
+		}
+		if opts.HeadingOffset == nil {
+			opts.HeadingOffset = c.HeadingOffset
+		}
+		if opts.NestHeadings == nil {
+			opts.NestHeadings = c.NestHeadings
+		}
+		if opts.LinkIdentifiers == nil {
+			opts.LinkIdentifiers = c.LinkIdentifiers
+		}
+		if opts.RawMarkdown == nil {
+			opts.RawMarkdown = c.RawMarkdown
+		}
+		opts.Defs = mergeDefs(opts.Defs, c.Defs)
+	}
+	return &Generator{opts: opts}, nil
+}
+
+// mergeDefs returns the Defs of the map m, followed by defs, so that defs take
+// precedence.  As setDef merges nested maps, the keys of the nested maps of m
+// that are not given by defs, such as by a dotted name, are kept.
+func mergeDefs(defs []Def, m map[string]interface{}) []Def {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var merged []Def
+	for _, name := range names {
+		merged = append(merged, Def{Name: strings.ToLower(name), Value: m[name]})
+	}
+	return append(merged, defs...)
+}
@@ -0,0 +1,215 @@
+// Copyright 2021 John Papandriopoulos. All rights reserved.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A Def is an additional template variable.  A dotted name, such as
+// "links.docs", sets a key of a nested map in the template data.
+type Def struct {
+	Name  string
+	Value interface{} // a string, or structured data such as a list or map
+}
+
+// UpperClone returns a clone of the receiver, with the first character of the
+// name in upper case.
+func (d Def) UpperClone() Def {
+	return Def{
+		Name:  upperFirst(d.Name),
+		Value: d.Value,
+	}
+}
+
+// upperFirst returns s with its first character in upper case.
+func upperFirst(s string) string {
+	rs := []rune(s)
+	if len(rs) == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(rs[0])) + string(rs[1:])
+}
+
+// checkDefName returns an error if the dotted name of a Def has an empty key,
+// such as "" or "a..b".
+func checkDefName(name string) error {
+	for _, key := range strings.Split(name, ".") {
+		if key == "" {
+			return fmt.Errorf("empty key in def name %q", name)
+		}
+	}
+	return nil
+}
+
+// setDef sets the variable of d in the template data m, with both a lowercase
+// and uppercase first character, as per UpperClone.  Each element of a dotted
+// name is a key of a nested map, that is created if necessary, and is also
+// available with both cases, as are the keys of the maps within the value.  If
+// the variable is already a map, and so is the value, the value is merged into
+// it, key by key, so that a later Def sets the keys of the nested maps of an
+// earlier one without losing the rest.  It fails if the name has an empty key.
+func setDef(m map[string]interface{}, d Def) error {
+	if err := checkDefName(d.Name); err != nil {
+		return err
+	}
+	keys := strings.Split(d.Name, ".")
+	for _, key := range keys[:len(keys)-1] {
+		sub, ok := m[key].(map[string]interface{})
+		if ok {
+			sub = cloneMap(sub) // it may be shared by the value of another Def
+		} else {
+			sub = make(map[string]interface{})
+		}
+		m[key] = sub
+		m[upperFirst(key)] = sub
+		m = sub
+	}
+	key := keys[len(keys)-1]
+	v := mergeDefValue(m[key], withUpperKeys(d.Value))
+	m[key] = v
+	m[upperFirst(key)] = v
+	return nil
+}
+
+// withUpperKeys returns v with the keys of its maps, and those nested within
+// them or within lists, also available with an uppercase first character,
+// unless already given.  The maps and lists are copied, rather than modified.
+func withUpperKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, 2*len(v))
+		for key, e := range v {
+			e = withUpperKeys(e)
+			m[key] = e
+			if key == "" {
+				continue
+			}
+			if _, ok := v[upperFirst(key)]; !ok {
+				m[upperFirst(key)] = e
+			}
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = withUpperKeys(e)
+		}
+		return l
+	}
+	return v
+}
+
+// mergeDefValue returns the value v merged into old: if both are maps, a copy
+// of old with each key of v merged into it, recursively, else v.
+func mergeDefValue(old, v interface{}) interface{} {
+	om, ok := old.(map[string]interface{})
+	vm, vok := v.(map[string]interface{})
+	if !ok || !vok {
+		return v
+	}
+	m := cloneMap(om)
+	for key, e := range vm {
+		m[key] = mergeDefValue(om[key], e)
+	}
+	return m
+}
+
+// cloneMap returns a shallow copy of m.
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for key, e := range m {
+		c[key] = e
+	}
+	return c
+}
+
+// A DefFlag is a flag.Value that collects Defs of the form name=value, with
+// the name in lowercase.
+type DefFlag []Def
+
+func (df *DefFlag) String() string {
+	return "" // Not used
+}
+
+func (df *DefFlag) Set(value string) error {
+	return df.set(value, func(s string) (interface{}, error) {
+		return s, nil
+	})
+}
+
+// JSON returns a flag.Value that collects Defs of the form name=json into the
+// receiver, where the value is decoded from JSON.
+func (df *DefFlag) JSON() flag.Value {
+	return defFuncFlag(func(value string) error {
+		return df.set(value, func(s string) (interface{}, error) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(s), &v); err != nil {
+				return nil, fmt.Errorf("invalid def flag: %w", err)
+			}
+			return v, nil
+		})
+	})
+}
+
+// File returns a flag.Value that collects Defs of the form name=path into the
+// receiver, where the value is decoded from the JSON or YAML file at path.
+func (df *DefFlag) File() flag.Value {
+	return defFuncFlag(func(value string) error {
+		return df.set(value, func(path string) (interface{}, error) {
+			bs, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			// YAML is a superset of JSON.
+			var v interface{}
+			if err := yaml.Unmarshal(bs, &v); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			return v, nil
+		})
+	})
+}
+
+// set adds the Def of the form name=value, where the value is decoded by
+// decode.
+func (df *DefFlag) set(value string, decode func(string) (interface{}, error)) error {
+	// Make sure it has the form $name=$value
+	i := strings.Index(value, "=")
+	if i <= 0 {
+		return errors.New("invalid def flag: missing equals token")
+	}
+	name := strings.ToLower(value[:i])
+	if err := checkDefName(name); err != nil {
+		return fmt.Errorf("invalid def flag: %w", err)
+	}
+	v, err := decode(value[i+1:])
+	if err != nil {
+		return err
+	}
+	*df = append(*df, Def{
+		Name:  name,
+		Value: v,
+	})
+	return nil
+}
+
+// defFuncFlag is a flag.Value that calls the function on Set.
+type defFuncFlag func(value string) error
+
+func (f defFuncFlag) String() string {
+	return "" // Not used
+}
+
+func (f defFuncFlag) Set(value string) error {
+	return f(value)
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 John Papandriopoulos. All rights reserved.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetDef(t *testing.T) {
+	m := make(map[string]interface{})
+	defs := mergeDefs([]Def{{Name: "links.docs", Value: "flag"}}, map[string]interface{}{
+		"links": map[string]interface{}{"docs": "cfg", "site": "cfgsite"},
+	})
+	for _, d := range defs {
+		if err := setDef(m, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]interface{}{"docs": "flag", "Docs": "flag", "site": "cfgsite", "Site": "cfgsite"}
+	for _, key := range []string{"links", "Links"} {
+		if got := m[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("m[%q] = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestSetDefEmptyKey(t *testing.T) {
+	for _, name := range []string{"", "a..b", "a.", ".a"} {
+		if err := setDef(make(map[string]interface{}), Def{Name: name, Value: "x"}); err == nil {
+			t.Errorf("setDef(%q) succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines surrounding each hunk.
+const diffContext = 3
+
+// A diffOp is a line of a diff: kind is one of ' ', '-' or '+' for unchanged,
+// deleted and inserted lines respectively.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns a unified diff from a to b, labelled with the names aName
+// and bName, or "" if they are the same.
+func unifiedDiff(aName, bName string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+	ops := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	// Line numbers in a and b, prior to each op.
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aPos[k+1], bPos[k+1] = aPos[k], bPos[k]
+		if op.kind != '+' {
+			aPos[k+1]++
+		}
+		if op.kind != '-' {
+			bPos[k+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Found a change: extend the hunk until there is a run of unchanged
+		// lines long enough to separate it from the next.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			k := end
+			for k < len(ops) && ops[k].kind == ' ' {
+				k++
+			}
+			if k == len(ops) || k-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = k
+		}
+
+		aStart, aLen := aPos[start], aPos[end]-aPos[start]
+		bStart, bLen := bPos[start], bPos[end]-bPos[start]
+		if aLen > 0 {
+			aStart++
+		}
+		if bLen > 0 {
+			bStart++
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// splitLines splits s into lines, each retaining its trailing newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the edits that transform a into b, computed with the
+// linear space variant of the Myers diff algorithm, so that a shortest edit
+// script is found without quadratic memory.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	diffRange(a, b, &ops)
+	return ops
+}
+
+// diffRange appends the edits that transform a into b to ops.
+func diffRange(a, b []string, ops *[]diffOp) {
+	// Trim the common prefix and suffix, as most of the lines of a stale README
+	// are typically unchanged.
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	s := 0
+	for s < len(a)-p && s < len(b)-p && a[len(a)-1-s] == b[len(b)-1-s] {
+		s++
+	}
+	for _, line := range a[:p] {
+		*ops = append(*ops, diffOp{' ', line})
+	}
+	suffix := a[len(a)-s:]
+	a, b = a[p:len(a)-s], b[p:len(b)-s]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			*ops = append(*ops, diffOp{'+', line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			*ops = append(*ops, diffOp{'-', line})
+		}
+	default:
+		// Having trimmed both ends, at least two edits remain, so each half
+		// has fewer edits than the whole and the recursion terminates.
+		x, y, u, v := middleSnake(a, b)
+		diffRange(a[:x], b[:y], ops)
+		for _, line := range a[x:u] {
+			*ops = append(*ops, diffOp{' ', line})
+		}
+		diffRange(a[u:], b[v:], ops)
+	}
+
+	for _, line := range suffix {
+		*ops = append(*ops, diffOp{' ', line})
+	}
+}
+
+// middleSnake returns the middle snake of a shortest edit script from a to b:
+// the run of unchanged lines from a[x:u] to b[y:v], found by searching forward
+// from the start and backward from the end of a and b at once, until the
+// searches overlap.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	max := (n + m + 1) / 2
+
+	// fwd[off+k] is the furthest x reached on diagonal k = x-y searching
+	// forward, and bwd[off+k] likewise searching backward, from the ends of a
+	// and b.
+	off := max + 1
+	fwd := make([]int, 2*max+3)
+	bwd := make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && fwd[off+k-1] < fwd[off+k+1]) {
+				x = fwd[off+k+1]
+			} else {
+				x = fwd[off+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			fwd[off+k] = u
+			if kr := delta - k; delta%2 != 0 && -(d-1) <= kr && kr <= d-1 && u+bwd[off+kr] >= n {
+				return x, y, u, v
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && bwd[off+k-1] < bwd[off+k+1]) {
+				x = bwd[off+k+1]
+			} else {
+				x = bwd[off+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[n-1-u] == b[m-1-v] {
+				u++
+				v++
+			}
+			bwd[off+k] = u
+			if kf := delta - k; delta%2 == 0 && -d <= kf && kf <= d && fwd[off+kf]+u >= n {
+				return n - u, m - v, n - x, m - y
+			}
+		}
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,286 @@
+// Copyright 2013 James Frasche. All rights reserved.
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var gopaths = build.Default.SrcDirs()
+
+// Doc is the documentation of a package, that is rendered into its README.
+type Doc struct {
+	Name            string
+	ImportPath      string
+	Synopsis        string
+	Doc             string
+	Title           string
+	RepoPath        string
+	IsLibrary       bool
+	Bugs            []string
+	Commands        []string  // import path of any cmd/* subpackages
+	HasTravis       bool      // true when a `.travis.yml` file is found, as per CI
+	CI              []CI      // CI pipelines of the package, module or repo
+	Licenses        []License // licenses of the package or its module
+	Badges          []Badge   // badges given by the Badges option, in order
+	Examples        map[string]Example
+	PackageExamples []Example // examples not associated with a particular symbol
+	API             API       // exported consts, vars, funcs and types
+	Packages        []Package // sub-packages, when generating an index
+	Module          *Module   // module containing the package, if any
+	RelPath         string    // path of the package relative to the module root
+	Repo            *Repo     // source repository of the package, if detected
+}
+
+// Package is the summary of a package, for use in an index of packages.
+type Package struct {
+	Name       string
+	ImportPath string
+	Synopsis   string
+	IsLibrary  bool
+	Commands   []string
+	Readme     string // relative path of the package README from the index
+}
+
+// Example is an example from a _test.go file, rendered as Markdown.
+type Example struct {
+	Name   string
+	Symbol string // the symbol exemplified, e.g. "T" or "T.M", or "" for the package
+	Suffix string // the example name suffix, e.g. "foo" for ExampleT_foo
+	Code   string
+	Output string // the expected output, if not ""
+}
+
+// Map returns the receiver as a map for use with a template.
+func (d Doc) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"Name":            d.Name,
+		"ImportPath":      d.ImportPath,
+		"Synopsis":        d.Synopsis,
+		"Doc":             d.Doc,
+		"Today":           time.Now().Format("2006.01.02"),
+		"Title":           d.Title,
+		"RepoPath":        d.RepoPath,
+		"Bugs":            d.Bugs,
+		"Library":         d.IsLibrary,
+		"Commands":        d.Commands,
+		"Travis":          d.HasTravis,
+		"CI":              d.CI,
+		"Licenses":        d.Licenses,
+		"Badges":          d.Badges,
+		"Examples":        d.Examples,
+		"PackageExamples": d.PackageExamples,
+		"API":             d.API,
+		"Packages":        d.Packages,
+		"Module":          d.Module,
+		"RelPath":         d.RelPath,
+		"Repo":            d.Repo,
+	}
+}
+
+// Summary returns the summary of the receiver, with the package README at the
+// relative path readme.
+func (d Doc) Summary(readme string) Package {
+	return Package{
+		Name:       d.Name,
+		ImportPath: d.ImportPath,
+		Synopsis:   d.Synopsis,
+		IsLibrary:  d.IsLibrary,
+		Commands:   d.Commands,
+		Readme:     readme,
+	}
+}
+
+// allExamples calls fn for each example in pkg, along with the name of the
+// symbol it exemplifies.
+func allExamples(pkg *doc.Package, fn func(symbol string, ex *doc.Example)) {
+	for _, ex := range pkg.Examples {
+		fn("", ex)
+	}
+	for _, f := range pkg.Funcs {
+		for _, ex := range f.Examples {
+			fn(f.Name, ex)
+		}
+	}
+	for _, t := range pkg.Types {
+		for _, ex := range t.Examples {
+			fn(t.Name, ex)
+		}
+		for _, f := range t.Funcs {
+			for _, ex := range f.Examples {
+				fn(f.Name, ex)
+			}
+		}
+		for _, m := range t.Methods {
+			for _, ex := range m.Examples {
+				fn(t.Name+"."+m.Name, ex)
+			}
+		}
+	}
+}
+
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, p := range gopaths {
+		p, err = filepath.Rel(p, abs)
+		if err != nil {
+			return "", err
+		}
+		//not a relative path, therefore the correct one
+		if len(p) > 0 && p[0] != '.' {
+			return filepath.ToSlash(p), nil
+		}
+	}
+	return "", fmt.Errorf("Not in go root or element of $GOPATH: %s", dir)
+}
+
+// docLoadMode is the packages.LoadMode required by newDoc.
+const docLoadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedSyntax |
+	packages.NeedTypes |
+	packages.NeedImports |
+	packages.NeedDeps |
+	packages.NeedModule
+
+// NewDoc loads the package in dir, and returns its documentation.
+func (g *Generator) NewDoc(dir string) (Doc, error) {
+	pkgs, err := loadPackages(dir, docLoadMode, ".")
+	if err != nil {
+		return Doc{}, err
+	}
+	if len(pkgs) != 1 {
+		return Doc{}, fmt.Errorf("found more than one package: %s", dir)
+	}
+	pg, err := g.withConfig(dir, moduleDir(pkgs[0].Package))
+	if err != nil {
+		return Doc{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	return pg.newDoc(pkgs[0])
+}
+
+// newDoc returns the documentation of the loaded package lp, which must have
+// been loaded with docLoadMode.  If lp failed to load, its error is returned,
+// unless tolerating errors and lp has been parsed.
+func (g *Generator) newDoc(lp *loadedPackage) (d Doc, err error) {
+	pkg := lp.Package
+	if lp.Err != nil && (!g.opts.TolerateErrors || len(pkg.Syntax) == 0) {
+		// Unless tolerating errors, and there is syntax to render docs from.
+		err = lp.Err
+		return
+	}
+	dir, err := goPackagesDir(pkg)
+	if err != nil {
+		return
+	}
+
+	d.ImportPath = pkg.PkgPath
+
+	// Detect the repo before the syntax is edited by doc.NewFromFiles below,
+	// which drops the import comment.
+	d.Repo = detectRepo(dir, pkg.Syntax, pkg.PkgPath)
+
+	// Parse package docs, together with the _test.go files of the test variants
+	// so that examples are associated with their corresponding symbols.
+	files := append(append([]*ast.File{}, pkg.Syntax...), testFiles(lp.Tests)...)
+	docPkg, err := doc.NewFromFiles(pkg.Fset, files, pkg.PkgPath)
+	if err != nil {
+		return
+	}
+	r := newDocRenderer(pkg, g.opts)
+	if value(g.opts.LinkIdentifiers) {
+		r.anchors = apiAnchors(docPkg)
+	}
+	d.Doc = r.packageDocString(docPkg)
+	d.Synopsis = docPkg.Synopsis(docPkg.Doc)
+	d.API = newAPI(pkg.Fset, r, docPkg)
+
+	// Render examples
+	d.Examples = make(map[string]Example)
+	for _, ex := range docPkg.Examples {
+		d.PackageExamples = append(d.PackageExamples, renderExample(pkg.Fset, ex, ""))
+	}
+	allExamples(docPkg, func(symbol string, ex *doc.Example) {
+		d.Examples[ex.Name] = renderExample(pkg.Fset, ex, symbol)
+	})
+
+	// Render bugs
+	for _, bug := range docPkg.Notes["BUG"] {
+		d.Bugs = append(d.Bugs, bug.Body)
+	}
+
+	name := pkg.Name
+	if name == "main" {
+		// main package: get the package name from the import path
+		name = filepath.Base(dir)
+	}
+
+	if d.Module, err = newModule(pkg); err != nil {
+		return
+	}
+	d.RelPath = relPath(d.Module, pkg.PkgPath)
+
+	// Strip the first path component of the import path to derive the repo path.
+	pathelms := strings.Split(pkg.PkgPath, "/")[1:]
+	d.RepoPath = path.Join(pathelms...)
+
+	d.Name = name
+	d.Title = name
+	if title := value(g.opts.Title); title != "" {
+		d.Title = title
+	}
+
+	if pkg.Name == "main" {
+		d.Commands = append(d.Commands, pkg.PkgPath)
+	} else {
+		d.IsLibrary = true
+	}
+
+	// Look for additional cmd/* subdirs in the package, that correspond to main packages.
+	if dirs, err := filepath.Glob(filepath.Join(dir, "cmd/*")); err == nil {
+		for _, dir := range dirs {
+			cmdPkg, err := loadPackage(dir, packages.NeedName)
+			if err != nil {
+				continue // ignore it
+			}
+			if cmdPkg.Name == "main" {
+				d.Commands = append(d.Commands, cmdPkg.PkgPath)
+			}
+		}
+	}
+
+	d.CI = detectCI(dir, d.Module, d.Repo)
+	for _, ci := range d.CI {
+		if ci.System == "travis" {
+			d.HasTravis = true
+		}
+	}
+
+	readmeDir := filepath.Dir(g.readmePath(dir))
+	if d.Licenses, err = detectLicenses(dir, readmeDir, d.Module); err != nil {
+		return
+	}
+
+	badges := g.opts.Badges
+	if badges == nil {
+		badges = DefaultBadges
+	}
+	d.Badges, err = newBadges(&d, badges)
+
+	return
+}
@@ -0,0 +1,536 @@
+// Copyright 2013 James Frasche. All rights reserved.
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/doc/comment"
+	"go/format"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/lexers"
+	"golang.org/x/tools/go/packages"
+)
+
+var regexpNumberedItem = regexp.MustCompile(`^[0-9]+\.`)
+
+// regexpExampleOutput matches the text of the output comment of an example, as
+// per go/doc.
+var regexpExampleOutput = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
+
+// A docRenderer renders the godoc comments of a package as Markdown.
+type docRenderer struct {
+	parser        *comment.Parser
+	importPath    string // import path of the package, for doc links
+	baseURL       string // base URL of the doc server, for doc links
+	headingOffset int    // added to the level of headings
+
+	// anchors are the anchors of the API sections, keyed by the identifiers
+	// that are linked to them, or nil to not link identifiers.
+	anchors map[string]string
+
+	// rawMarkdown passes the plain text of doc comments through as-is, rather
+	// than escaping Markdown metacharacters.
+	rawMarkdown bool
+}
+
+// newDocRenderer returns a renderer for the doc comments of pkg.  Doc links
+// are resolved using the types and imports of pkg, and link to the doc server
+// given by opts, which also control the level of headings.
+func newDocRenderer(pkg *packages.Package, opts Options) *docRenderer {
+	return &docRenderer{
+		parser: &comment.Parser{
+			LookupPackage: lookupPackageFunc(pkg),
+			LookupSym:     lookupSymFunc(pkg),
+		},
+		importPath:    pkg.PkgPath,
+		baseURL:       strings.TrimSuffix(opts.DocBaseURL, "/"),
+		headingOffset: value(opts.HeadingOffset),
+		rawMarkdown:   value(opts.RawMarkdown),
+	}
+}
+
+// lookupPackageFunc returns a function that resolves a package name to the
+// import path of a package imported by pkg.  The name of pkg itself resolves to
+// the current package.
+func lookupPackageFunc(pkg *packages.Package) func(name string) (string, bool) {
+	imports := make(map[string]string)
+	for _, f := range pkg.Syntax {
+		for _, spec := range f.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			var name string
+			if spec.Name != nil {
+				name = spec.Name.Name
+			} else if imp, ok := pkg.Imports[path]; ok {
+				name = imp.Name
+			} else {
+				continue
+			}
+			if name == "_" || name == "." {
+				continue
+			}
+			imports[name] = path
+		}
+	}
+	return func(name string) (string, bool) {
+		if name == pkg.Name {
+			return "", true
+		}
+		path, ok := imports[name]
+		return path, ok
+	}
+}
+
+// lookupSymFunc returns a function that reports whether the exported symbol
+// name, or method name of type recv, is declared in pkg.
+func lookupSymFunc(pkg *packages.Package) func(recv, name string) bool {
+	return func(recv, name string) bool {
+		if pkg.Types == nil {
+			return false
+		}
+		scope := pkg.Types.Scope()
+		if recv == "" {
+			obj := scope.Lookup(name)
+			return obj != nil && obj.Exported()
+		}
+		tn, ok := scope.Lookup(recv).(*types.TypeName)
+		if !ok {
+			return false
+		}
+		obj, _, _ := types.LookupFieldOrMethod(tn.Type(), true, pkg.Types, name)
+		_, ok = obj.(*types.Func)
+		return ok && obj.Exported()
+	}
+}
+
+// packageDocString renders the package documentation of pkg as Markdown.
+func (r *docRenderer) packageDocString(pkg *doc.Package) string {
+	return r.docString(pkg.Doc)
+}
+
+// docString renders the godoc comment text as Markdown, with the level of its
+// headings shifted by the heading offset.
+func (r *docRenderer) docString(text string) string {
+	return r.symbolDocString(text, "")
+}
+
+// symbolDocString renders the godoc comment text of symbol as Markdown, as per
+// docString, without linking the identifier of symbol.
+func (r *docRenderer) symbolDocString(text, symbol string) string {
+	d := r.parser.Parse(text)
+	p := &markdownPrinter{
+		r:         r,
+		lines:     strings.Split(text, "\n"),
+		self:      symbol,
+		escapeAll: !r.rawMarkdown && newStyleDoc(d, text),
+	}
+	for _, b := range d.Content {
+		p.block(b)
+	}
+	return shiftHeadings(r.headingOffset, p.out.String())
+}
+
+// A markdownPrinter holds the state needed to print a parsed doc comment as
+// GitHub-flavored Markdown.
+type markdownPrinter struct {
+	r   *docRenderer
+	out bytes.Buffer
+
+	// lines are the lines of the doc comment, and line is the index of the
+	// line following the last list printed, used to recover the indentation
+	// of list items that the parser drops.
+	lines []string
+	line  int
+
+	// list holds the open items of a list of paragraphs, whose subsequent
+	// paragraphs are indented as part of the innermost item.
+	//
+	// Godoc comments written before Go 1.19 have no list syntax, so we detect
+	// lists from the paragraphs that start with "^[0-9]+\." or "* ", and nested
+	// items from those numbered as 1.1., 1.1.1., 1.1.2., etc.  We then assume
+	// all of the subsequent paragraphs in the section are part of the same
+	// list, until we find another list item.
+	//
+	// The only ambiguity here is a regular paragraph following a list, so
+	// consider a non-standard "section separator" paragraph of "...", which
+	// closes the list, and which we will elide.
+	list listFrames
+
+	// The state of the inline Markdown of the plain text of a block: the depth
+	// of the brackets of link texts, and whether within a link destination.
+	// Identifiers are not linked while noIdents is positive.
+	brackets int
+	dest     bool
+	noIdents int
+
+	// escapeAll escapes all of the Markdown metacharacters of the text, for
+	// doc comments that use the Go 1.19 syntax rather than Markdown.
+	escapeAll bool
+
+	// self is the identifier of the symbol whose doc comment is printed, that
+	// is not linked to itself, if any.
+	self string
+}
+
+// block prints the block x.
+func (p *markdownPrinter) block(x comment.Block) {
+	p.brackets, p.dest = 0, false
+	switch x := x.(type) {
+	case *comment.Paragraph:
+		var b bytes.Buffer
+		p.text(&b, x.Text)
+		par := strings.TrimSpace(b.String())
+
+		nums, n, nested := nestedItem(par, false)
+		switch {
+		case par == "...":
+			// Found a section separator: remove it.
+			p.list = nil
+			return
+
+		case p.escapeAll:
+			// Lists have their own syntax, so the paragraph is only text.
+			par = escapeLineStarts(par)
+
+		case nested:
+			// Found a nested item, numbered as 1.1., etc.: indent it within
+			// the enclosing items, and indent its subsequent lines.
+			marker := nums[len(nums)-1] + ". "
+			p.list.popTo(len(nums) - 1)
+			indent := p.list.push(0, marker)
+			par = indent + marker + strings.ReplaceAll(par[n:], "\n", "\n"+p.list.pad(0))
+
+		case regexpNumberedItem.MatchString(par), strings.HasPrefix(par, "* "):
+			// Found a NEW numbered or bulleted list: indent subsequent pars.
+			p.list = nil
+			marker := par + " "
+			p.list.push(0, marker[:strings.IndexAny(marker, " \t\n")+1])
+
+		default:
+			if len(p.list) > 0 {
+				pad := p.list.pad(4)
+				par = pad + strings.ReplaceAll(par, "\n", "\n"+pad)
+			}
+		}
+		p.out.WriteString(par)
+		p.out.WriteString("\n\n")
+
+	case *comment.Heading:
+		p.list = nil
+		p.out.WriteString("## ")
+		p.noIdents++
+		p.text(&p.out, x.Text)
+		p.noIdents--
+		p.out.WriteString("\n\n")
+
+	case *comment.Code:
+		p.out.WriteString("```")
+		p.out.WriteString(codeLanguage(x.Text))
+		p.out.WriteString("\n")
+		p.out.WriteString(x.Text)
+		p.out.WriteString("```\n\n")
+
+	case *comment.List:
+		p.listBlock(x)
+	}
+}
+
+// listBlock prints the list x, nesting its items by their indentation in the
+// doc comment.  The parser only starts items with markers like that of the
+// first item, so a line of an item that starts with a bullet in a numbered
+// list, or vice versa, starts a nested item, as does a line that starts with a
+// hierarchical number such as "1.1.", or "1.1" when indented.
+func (p *markdownPrinter) listBlock(x *comment.List) {
+	indents := p.listIndents(x)
+	next := func() int {
+		if len(indents) == 0 {
+			return 0
+		}
+		n := indents[0]
+		indents = indents[1:]
+		return n
+	}
+
+	var list listFrames
+	loose := x.BlankBetween()
+	for i, item := range x.Items {
+		if i > 0 && loose {
+			p.out.WriteString("\n")
+		}
+		marker := "- "
+		if item.Number != "" {
+			marker = item.Number + ". "
+		}
+		indent := next()
+		list.popIndented(indent, 0)
+		p.out.WriteString(list.push(indent, marker))
+		p.out.WriteString(marker)
+		depth := len(list) // of the items nested within this one
+
+		for j, blk := range item.Content {
+			if j > 0 {
+				p.out.WriteString("\n")
+			}
+			var b bytes.Buffer
+			p.text(&b, blk.(*comment.Paragraph).Text)
+			for k, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+				if j == 0 && k == 0 {
+					p.out.WriteString(p.lineStart(line))
+					p.out.WriteString("\n")
+					continue
+				}
+				indent := next()
+				if p.escapeAll && strings.HasPrefix(line, "\\* ") {
+					// Undo the escape of a "*" bullet.
+					line = line[1:]
+				}
+				// A hierarchical number without a dot, such as "1.1", only
+				// starts an item when indented beyond the content of the
+				// innermost item, rather than continuing it.
+				if nums, n, ok := nestedItem(line, indent > list.contentIndent()); ok {
+					marker := nums[len(nums)-1] + ". "
+					list.popTo(depth + len(nums) - 2)
+					p.out.WriteString(list.push(indent, marker))
+					p.out.WriteString(marker)
+					line = line[n:]
+				} else if num, rest, ok := listMarker(line); ok {
+					marker := "- "
+					if num != "" {
+						marker = num + ". "
+					}
+					list.popIndented(indent, depth)
+					p.out.WriteString(list.push(indent, marker))
+					p.out.WriteString(marker)
+					line = rest
+				} else {
+					if k == 0 {
+						// A new paragraph belongs to the item that encloses it.
+						list.popIndented(indent, depth)
+					}
+					p.out.WriteString(list.pad(0))
+				}
+				p.out.WriteString(p.lineStart(line))
+				p.out.WriteString("\n")
+			}
+		}
+	}
+	p.out.WriteString("\n")
+}
+
+// lineStart returns line, as per escapeLineStart if escaping all.
+func (p *markdownPrinter) lineStart(line string) string {
+	if p.escapeAll {
+		return escapeLineStart(line)
+	}
+	return line
+}
+
+// listIndents returns the indentation of each non-blank line of the list x in
+// the doc comment, in order, or nil if the list could not be found.
+func (p *markdownPrinter) listIndents(x *comment.List) []int {
+	if len(x.Items) == 0 || len(x.Items[0].Content) == 0 {
+		return nil
+	}
+	first := x.Items[0]
+	var firstText string
+	if t, ok := first.Content[0].(*comment.Paragraph).Text[0].(comment.Plain); ok {
+		firstText = strings.SplitN(string(t), "\n", 2)[0]
+	}
+	n := 0
+	for _, item := range x.Items {
+		for _, blk := range item.Content {
+			n += 1 + textNewlines(blk.(*comment.Paragraph).Text)
+		}
+	}
+
+	for i := p.line; i < len(p.lines); i++ {
+		num, rest, ok := listMarker(p.lines[i])
+		if !ok || num != first.Number || !strings.HasPrefix(rest, firstText) {
+			continue
+		}
+		var indents []int
+		for ; i < len(p.lines) && len(indents) < n; i++ {
+			if strings.TrimSpace(p.lines[i]) != "" {
+				indents = append(indents, indentWidth(p.lines[i]))
+			}
+		}
+		p.line = i
+		return indents
+	}
+	return nil
+}
+
+// textNewlines returns the number of newlines in the text sequence x.
+func textNewlines(x []comment.Text) int {
+	n := 0
+	for _, t := range x {
+		switch t := t.(type) {
+		case comment.Plain:
+			n += strings.Count(string(t), "\n")
+		case comment.Italic:
+			n += strings.Count(string(t), "\n")
+		case *comment.Link:
+			n += textNewlines(t.Text)
+		case *comment.DocLink:
+			n += textNewlines(t.Text)
+		}
+	}
+	return n
+}
+
+// text prints the text sequence x to out.  Plain text is printed by plain, so
+// that Markdown written in doc comments is preserved.
+func (p *markdownPrinter) text(out *bytes.Buffer, x []comment.Text) {
+	for _, t := range x {
+		switch t := t.(type) {
+		case comment.Plain:
+			p.plain(out, string(t))
+		case comment.Italic:
+			out.WriteString("_")
+			out.WriteString(string(t))
+			out.WriteString("_")
+		case *comment.Link:
+			if t.Auto {
+				// A bare URL in the text: make it an autolink, unless it is
+				// the destination of a link written in Markdown.
+				if p.dest {
+					out.WriteString(t.URL)
+				} else {
+					out.WriteString("<" + t.URL + ">")
+				}
+				break
+			}
+			out.WriteString("[")
+			p.linkText(out, t.Text)
+			out.WriteString("](")
+			out.WriteString(t.URL)
+			out.WriteString(")")
+		case *comment.DocLink:
+			out.WriteString("[")
+			p.linkText(out, t.Text)
+			out.WriteString("](")
+			out.WriteString(p.r.docLinkURL(t))
+			out.WriteString(")")
+		}
+	}
+}
+
+// linkText prints the text sequence x of a link to out, without linking its
+// identifiers.
+func (p *markdownPrinter) linkText(out *bytes.Buffer, x []comment.Text) {
+	p.noIdents++
+	p.text(out, x)
+	p.noIdents--
+}
+
+// docLinkURL returns the URL of the doc link l on the doc server.
+func (r *docRenderer) docLinkURL(l *comment.DocLink) string {
+	link := *l
+	if link.ImportPath == "" {
+		// Link to the current package on the doc server, rather than to an
+		// anchor in the README.
+		link.ImportPath = r.importPath
+	}
+	url := link.DefaultURL(r.baseURL)
+	url = strings.ReplaceAll(url, "(", "%28")
+	url = strings.ReplaceAll(url, ")", "%29")
+	return url
+}
+
+// codeLanguage returns the Markdown code block language of code, or "" if it
+// could not be determined.
+func codeLanguage(code string) string {
+	// Detect language... and hope the lexer name is the same as the lingust
+	// name.  GitHub Markdown uses linguist:
+	// https://github.com/github/linguist/blob/master/lib/linguist/languages.yml
+	//
+	// It turns out using alecthomas/chroma uses a far-too-basic heuristic for
+	// detecting Go, and so we add a more comprehensive heuristic here instead.
+	// Given that we're mostly going to find Go code here, we lean towards
+	// detecting it over not.
+	//
+	// We then defer to the generic analyzer following.
+	if strings.Contains(code, "package ") ||
+		strings.Contains(code, "func(") ||
+		strings.Contains(code, " := ") ||
+		strings.Contains(code, "fmt.") ||
+		strings.Contains(code, "var ") {
+		return "go"
+	} else if lexer := lexers.Analyse(code); lexer != nil {
+		return strings.ToLower(lexer.Config().Name)
+	}
+	return ""
+}
+
+// renderExample renders ex as Markdown.  The symbol is the name of the symbol
+// it exemplifies, or "" for a package-level example.  The fset must be the one
+// used to parse the example, so that its comments are kept.
+func renderExample(fset *token.FileSet, ex *doc.Example, symbol string) Example {
+	e := Example{
+		Name:   strings.Replace(ex.Name, "_", " ", -1),
+		Symbol: symbol,
+		Suffix: ex.Suffix,
+	}
+
+	// Strip the output comment, like godoc.
+	out := exampleOutputComment(ex)
+	var comments []*ast.CommentGroup
+	for _, cg := range ex.Comments {
+		if cg != out {
+			comments = append(comments, cg)
+		}
+	}
+
+	c := &bytes.Buffer{}
+	format.Node(c, fset, &printer.CommentedNode{Node: ex.Code, Comments: comments})
+	code := c.String()
+	if _, ok := ex.Code.(*ast.BlockStmt); ok {
+		// Strip the braces of the example function body, like godoc.
+		code = strings.TrimPrefix(strings.TrimSuffix(code, "}"), "{\n")
+		code = strings.ReplaceAll(strings.TrimPrefix(code, "\t"), "\n\t", "\n")
+		code = strings.TrimSpace(code)
+	}
+	e.Code = fmt.Sprintf("Code:\n\n```go\n%s\n```\n", code)
+
+	if ex.Output != "" {
+		e.Output = fmt.Sprintf("Output:\n\n```\n%s\n```\n", strings.TrimSuffix(ex.Output, "\n"))
+	}
+
+	return e
+}
+
+// exampleOutputComment returns the output comment of the example function ex,
+// from which go/doc takes its output: the last comment of its body, following
+// its code, if it starts with "Output:".  It returns nil if there is none.
+func exampleOutputComment(ex *doc.Example) *ast.CommentGroup {
+	body, ok := ex.Code.(*ast.BlockStmt)
+	if !ok {
+		return nil
+	}
+	var last *ast.CommentGroup
+	for _, cg := range ex.Comments {
+		if body.Lbrace < cg.Pos() && cg.End() < body.Rbrace {
+			last = cg
+		}
+	}
+	if last == nil || !regexpExampleOutput.MatchString(last.Text()) {
+		return nil
+	}
+	if n := len(body.List); n > 0 && body.List[n-1].End() > last.Pos() {
+		return nil
+	}
+	return last
+}
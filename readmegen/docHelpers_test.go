@@ -0,0 +1,124 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"go/doc"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestPackageDocStringLists(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "legacy numbered list",
+			doc:  "Intro.\n\n1. First\n\nMore of the first.\n\n2. Second\n",
+			want: "Intro.\n\n1. First\n\n    More of the first.\n\n2. Second\n\n",
+		},
+		{
+			name: "legacy bulleted list",
+			doc:  "* First\n\nMore of the first.\n",
+			want: "* First\n\n    More of the first.\n\n",
+		},
+		{
+			name: "legacy new list closes the previous list",
+			doc:  "1. First\n\n* Bullet\n\nMore of the bullet.\n",
+			want: "1. First\n\n* Bullet\n\n    More of the bullet.\n\n",
+		},
+		{
+			name: "legacy numbered paragraph without text",
+			doc:  "Since\n\n2021.\n\nMore text.\n",
+			want: "Since\n\n2021.\n\n      More text.\n\n",
+		},
+		{
+			name: "legacy version number paragraph",
+			doc:  "Version\n\n1.5\n",
+			want: "Version\n\n1.5\n\n",
+		},
+		{
+			name: "legacy hierarchical numbers",
+			doc:  "1. Fruit\n\n1.1. Apple\n\nMore of the apple.\n\n1.1.1. Granny Smith\n\n2. Vegetables\n",
+			want: "1. Fruit\n\n   1. Apple\n\n      More of the apple.\n\n      1. Granny Smith\n\n2. Vegetables\n\n",
+		},
+		{
+			name: "legacy hierarchical number without a dot",
+			doc:  "1.1 is not an item.\n",
+			want: "1.1 is not an item.\n\n",
+		},
+		{
+			name: "terminator",
+			doc:  "1. First\n\nMore of the first.\n\n...\n\nAfter the list.\n",
+			want: "1. First\n\n    More of the first.\n\nAfter the list.\n\n",
+		},
+		{
+			name: "terminator closes nested items",
+			doc:  "1. Fruit\n\n1.1. Apple\n\n...\n\nAfter the list.\n",
+			want: "1. Fruit\n\n   1. Apple\n\nAfter the list.\n\n",
+		},
+		{
+			name: "indented bullets",
+			doc:  "Intro:\n  - Fruit\n    - Apple\n    - Pear\n  - Vegetables\n",
+			want: "Intro:\n\n- Fruit\n  - Apple\n  - Pear\n- Vegetables\n\n",
+		},
+		{
+			name: "indented mixed and hierarchical items",
+			doc:  "Intro:\n 1. Fruit\n    - Apple\n    - Pear\n 2. Vegetables\n    2.1. Carrot\n    2.2. Potato\n",
+			want: "Intro:\n\n1. Fruit\n   - Apple\n   - Pear\n2. Vegetables\n   1. Carrot\n   2. Potato\n\n",
+		},
+		{
+			name: "indented version number continues the item",
+			doc:  "Requirements:\n  - Go version\n    1.22 or later is required.\n  - Git\n",
+			want: "Requirements:\n\n- Go version\n  1\\.22 or later is required.\n- Git\n\n",
+		},
+		{
+			name: "indented hierarchical number without a dot",
+			doc:  "Requirements:\n  - Go\n      1.1 Toolchain\n  - Git\n",
+			want: "Requirements:\n\n- Go\n  1. Toolchain\n- Git\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &docRenderer{parser: &comment.Parser{}}
+			if got := r.packageDocString(&doc.Package{Doc: tt.doc}); got != tt.want {
+				t.Errorf("packageDocString(%q)\n got %q\nwant %q", tt.doc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderExample(t *testing.T) {
+	const src = `package ex_test
+
+func Example() {
+	// output: is printed below
+	fmt.Println("hi") // inline
+	// Output:
+	// hi
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "ex_test.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exs := doc.Examples(f)
+	if len(exs) != 1 {
+		t.Fatalf("got %d examples, want 1", len(exs))
+	}
+	e := renderExample(fset, exs[0], "")
+	const wantCode = "Code:\n\n```go\n// output: is printed below\nfmt.Println(\"hi\") // inline\n```\n"
+	if e.Code != wantCode {
+		t.Errorf("Code\n got %q\nwant %q", e.Code, wantCode)
+	}
+	const wantOutput = "Output:\n\n```\nhi\n```\n"
+	if e.Output != wantOutput {
+		t.Errorf("Output\n got %q\nwant %q", e.Output, wantOutput)
+	}
+}
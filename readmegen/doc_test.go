@@ -0,0 +1,43 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewDocNestHeadings(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/ex\n\ngo 1.22\n",
+		"ex.go":  "// Package ex is an example.\n//\n// # Usage\n//\n// Call Hi.\npackage ex\n\n// Hi says hi.\n//\n// # Details\n//\n// More.\nfunc Hi() string { return \"hi\" }\n",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nest := true
+	d, err := New(Options{NestHeadings: &nest}).NewDoc(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The docs are only marked in the template data when rendering.
+	docs := []string{d.Doc}
+	for _, f := range d.API.Funcs {
+		docs = append(docs, f.Doc)
+	}
+	for _, doc := range docs {
+		if strings.Contains(doc, docStart) || strings.Contains(doc, docEnd) {
+			t.Errorf("doc %q is marked", doc)
+		}
+	}
+	if len(docs) != 2 || !strings.Contains(docs[1], "## Details") {
+		t.Errorf("got docs %q, want the package doc and that of Hi", docs)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// funcsHelp documents the template functions, and is printed as a comment at
+// the top of the builtin template.
+const funcsHelp = `{{- /*
+Template functions, in addition to those of text/template.  The last argument
+of each may be given by a pipeline, e.g. {{.Synopsis | lower}}.
+
+Strings:
+  lower S, upper S, title S     change the case of S; title upper cases words
+  trim S, trimPrefix P S, trimSuffix X S
+  replace OLD NEW S             replace all OLD in S with NEW
+  contains SUB S, hasPrefix P S, hasSuffix X S
+  split SEP S, join SEP LIST    split S into a list, or join LIST into a string
+  repeat N S, indent N S        repeat S N times, or indent each line by N spaces
+  quote S                       S as a double-quoted Go string
+  default DEF V                 V, or DEF if V is empty
+
+Lists:
+  list V...                     a list of the values
+  first L, last L, rest L       the first or last element of L, or all but the first
+  has V L                       true if L contains V
+  sortAlpha L, uniq L           L sorted as strings, or without duplicates
+
+Dates:
+  now                           the current time
+  date LAYOUT T                 T formatted per the Go layout, e.g. "2006-01-02"
+
+Markdown:
+  mdEscape S                    S with Markdown metacharacters escaped
+  codeFence LANG S              S as a fenced code block in the language LANG
+  shiftHeadings N MD            MD with the level of its headings shifted by N
+  toc [DEPTH] [MD]              a list of links to the headings of MD, else of the
+                                README, of the first DEPTH levels of headings
+  include PATH                  the file at PATH, relative to the package directory
+  badges [NAME...]              the badges with the given names, else .Badges
+*/ -}}
+`
+
+// templateFuncs returns the functions available to templates, as documented
+// by funcsHelp.  They are bound to the package in dir documented by d, or nil
+// for an index.  The toc function is rebound to the README by render.
+func templateFuncs(dir string, d *Doc) template.FuncMap {
+	return template.FuncMap{
+		// Strings
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"title":      titleCase,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(sub, s string) bool { return strings.Contains(s, sub) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       join,
+		"repeat":     func(n int, s string) string { return strings.Repeat(s, n) },
+		"indent":     indent,
+		"quote":      strconv.Quote,
+		"default":    defaultValue,
+
+		// Lists
+		"list":      func(vs ...interface{}) []interface{} { return vs },
+		"first":     first,
+		"last":      last,
+		"rest":      rest,
+		"has":       has,
+		"sortAlpha": sortAlpha,
+		"uniq":      uniq,
+
+		// Dates
+		"now":  time.Now,
+		"date": func(layout string, t time.Time) string { return t.Format(layout) },
+
+		// Markdown
+		"mdEscape":      mdEscape,
+		"codeFence":     codeFence,
+		"shiftHeadings": shiftHeadings,
+		"toc":           tocFunc("", 0),
+		"include": func(path string) (string, error) {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			bs, err := ioutil.ReadFile(path)
+			return string(bs), err
+		},
+		"badges": func(names ...string) (string, error) {
+			if d == nil {
+				return "", nil
+			}
+			badges := d.Badges
+			if len(names) > 0 {
+				var err error
+				if badges, err = newBadges(d, names); err != nil {
+					return "", err
+				}
+			}
+			mds := make([]string, len(badges))
+			for i, b := range badges {
+				mds[i] = b.Markdown()
+			}
+			return strings.Join(mds, " "), nil
+		},
+	}
+}
+
+// titleCase returns s with the first letter of each word in upper case.
+func titleCase(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(prev) {
+			r = unicode.ToUpper(r)
+		}
+		prev = r
+		return r
+	}, s)
+}
+
+// indent returns s with each non-empty line indented by n spaces.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// defaultValue returns v, or def if v is the zero value of its type, or an
+// empty slice or map.
+func defaultValue(def, v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.IsZero() {
+		return def
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		if rv.Len() == 0 {
+			return def
+		}
+	}
+	return v
+}
+
+// listValue returns the list l as a reflect.Value, or an error if it is not a
+// slice or array.
+func listValue(l interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(l)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv, nil
+	}
+	return rv, fmt.Errorf("expected a list, got %T", l)
+}
+
+func join(sep string, l interface{}) (string, error) {
+	rv, err := listValue(l)
+	if err != nil {
+		return "", err
+	}
+	ss := make([]string, rv.Len())
+	for i := range ss {
+		ss[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(ss, sep), nil
+}
+
+func first(l interface{}) (interface{}, error) {
+	rv, err := listValue(l)
+	if err != nil || rv.Len() == 0 {
+		return nil, err
+	}
+	return rv.Index(0).Interface(), nil
+}
+
+func last(l interface{}) (interface{}, error) {
+	rv, err := listValue(l)
+	if err != nil || rv.Len() == 0 {
+		return nil, err
+	}
+	return rv.Index(rv.Len() - 1).Interface(), nil
+}
+
+func rest(l interface{}) (interface{}, error) {
+	rv, err := listValue(l)
+	if err != nil || rv.Len() == 0 {
+		return nil, err
+	}
+	return rv.Slice(1, rv.Len()).Interface(), nil
+}
+
+func has(v, l interface{}) (bool, error) {
+	rv, err := listValue(l)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if reflect.DeepEqual(rv.Index(i).Interface(), v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func sortAlpha(l interface{}) ([]string, error) {
+	rv, err := listValue(l)
+	if err != nil {
+		return nil, err
+	}
+	ss := make([]string, rv.Len())
+	for i := range ss {
+		ss[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	sort.Strings(ss)
+	return ss, nil
+}
+
+func uniq(l interface{}) ([]interface{}, error) {
+	rv, err := listValue(l)
+	if err != nil {
+		return nil, err
+	}
+	var vs []interface{}
+	for i := 0; i < rv.Len(); i++ {
+		v := rv.Index(i).Interface()
+		if ok, _ := has(v, vs); !ok {
+			vs = append(vs, v)
+		}
+	}
+	return vs, nil
+}
+
+// tocFunc returns the toc template function, that returns the table of
+// contents of the Markdown given by an optional last argument, else of readme,
+// listing the number of heading levels given by an optional first argument,
+// else depth, as per tableOfContents.
+func tocFunc(readme string, depth int) func(args ...interface{}) (string, error) {
+	return func(args ...interface{}) (string, error) {
+		if len(args) > 2 {
+			return "", fmt.Errorf("toc: expected at most 2 arguments, got %d", len(args))
+		}
+		md, n := readme, depth
+		if len(args) > 0 {
+			if s, ok := args[len(args)-1].(string); ok {
+				md = s
+				args = args[:len(args)-1]
+			}
+		}
+		switch len(args) {
+		case 0:
+		case 1:
+			var ok bool
+			if n, ok = args[0].(int); !ok {
+				return "", fmt.Errorf("toc: expected an int depth, got %T", args[0])
+			}
+		default:
+			return "", fmt.Errorf("toc: expected Markdown, got %T", args[1])
+		}
+		return tableOfContents(md, n), nil
+	}
+}
@@ -0,0 +1,513 @@
+// Copyright 2013 James Frasche. All rights reserved.
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+// Package readmegen generates Markdown READMEs for Go packages from their
+// godoc.
+//
+// It is the library behind the godoc-readme-gen command, so that READMEs can
+// also be generated from your own tools:
+//
+//	g := readmegen.New(readmegen.Options{
+//		DocBaseURL: readmegen.DefaultDocBaseURL,
+//		ConfigFile: readmegen.DefaultConfigFile,
+//		Force:      true,
+//	})
+//	if err := g.Generate("./..."); err != nil {
+//		log.Fatal(err)
+//	}
+package readmegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultTemplateFile is the name of the template file used by the command,
+// when found in the package directory.
+const DefaultTemplateFile = ".README.template.md"
+
+// DefaultDocBaseURL is the base URL of the public Go doc server.
+const DefaultDocBaseURL = "https://pkg.go.dev"
+
+// Options configures a Generator.
+type Options struct {
+	// Title of the README.  If nil, the title of the config file is used, and
+	// if "", the package name.
+	Title *string
+
+	// Template is the path of the template file, relative to the package
+	// directory.  If nil, the template of the config file is used, else
+	// DefaultTemplateFile, and if DefaultTemplateFile is not found, the builtin
+	// template.  If "", the builtin template is used.
+	Template *string
+
+	// Defs are additional template variables.  Each is available with both a
+	// lowercase and uppercase first character, as are the keys of its nested
+	// maps, including those given by dotted names.  They take precedence over
+	// the defs of the config file, key by key for nested maps.
+	Defs []Def
+
+	// Badges are the names of the badges rendered beside the title, in order.
+	// If nil, DefaultBadges are rendered.
+	Badges []string
+
+	// DocBaseURL is the base URL of the doc server that doc links refer to.
+	DocBaseURL string
+
+	// The following options are left to the config file when nil, so that
+	// zero values given explicitly take precedence over it.  Otherwise, their
+	// defaults are the zero values.
+
+	// HeadingOffset is added to the level of the headings of doc comments,
+	// which are otherwise rendered at level 2, such as "## Heading".
+	HeadingOffset *int
+
+	// NestHeadings shifts the headings of each doc comment in the README, such
+	// as .Doc, to one level below the heading of the template that encloses it,
+	// taking precedence over HeadingOffset.  The docs are only marked in the
+	// template data for this, so the Doc of a package is unaffected.
+	NestHeadings *bool
+
+	// RawMarkdown passes the text of doc comments through as-is, for those that
+	// intentionally write Markdown.  Otherwise, the Markdown metacharacters of
+	// doc comments that use the Go 1.19 syntax are escaped, so that the README
+	// renders as godoc does, and only those that would format the text by
+	// accident are escaped in older doc comments.
+	RawMarkdown *bool
+
+	// LinkIdentifiers links the exported identifiers of the package in doc
+	// comments, such as "NewDoc" or "Doc.Map", to their sections of the API of
+	// the builtin template.
+	LinkIdentifiers *bool
+
+	// TOCDepth is the number of heading levels listed by the table of contents
+	// of the README, including its top level, or all levels if 0.
+	TOCDepth *int
+
+	// Output is the path of the README, relative to the package directory.  If
+	// nil, the output of the config file is used.  If "", README.md is used,
+	// and if "-", the README is written to Stdout.
+	Output *string
+
+	// ConfigFile is the name of the config file of each package, found in the
+	// package directory or one of its parents up to the module root, that
+	// provides the options not given here.  If "", no config file is used.
+	ConfigFile string
+
+	// Markers renders only the sections of the existing READMEs between
+	// markers, leaving the rest intact.  Each section is rendered by the
+	// template, such as a block of the builtin template, named by its start
+	// marker.
+	Markers bool
+
+	// Force overwrites existing READMEs.
+	Force bool
+
+	// Check compares the rendered README with the existing README, rather than
+	// writing it, and fails if they differ.
+	Check bool
+
+	// Index also generates an index of the packages, that links to the README
+	// of each package.
+	Index bool
+
+	// TolerateErrors renders docs from the parsed source of packages that fail
+	// to load, such as those that fail to type check.
+	TolerateErrors bool
+
+	// Stdout receives the READMEs when Output is "-", if not nil.
+	Stdout io.Writer
+
+	// Diff receives the diffs of stale READMEs in check mode, if not nil.
+	Diff io.Writer
+
+	// Logf logs warnings, such as tolerated load errors, if not nil.
+	Logf func(format string, v ...interface{})
+}
+
+// A Generator generates READMEs for Go packages.
+type Generator struct {
+	opts Options
+}
+
+// New returns a Generator with the given options.
+func New(opts Options) *Generator {
+	return &Generator{opts: opts}
+}
+
+// value returns the value of the optional option p, or its zero value if nil.
+func value[T any](p *T) (v T) {
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+func (g *Generator) logf(format string, v ...interface{}) {
+	if g.opts.Logf != nil {
+		g.opts.Logf(format, v...)
+	}
+}
+
+// Generate generates the README of each package named by args: either a single
+// package directory, or one or more package patterns such as "./...".  If no
+// args are given, the package in the working directory is used.
+//
+// If the README of a package fails to generate, the remaining packages are
+// still generated, and the errors of all packages are returned together.
+func (g *Generator) Generate(args ...string) error {
+	pkgs, err := loadArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Load the docs of each package, collecting errors rather than giving up on
+	// the remaining packages.
+	type pkgDoc struct {
+		dir string
+		doc Doc
+		g   *Generator // with the options of the package's config file
+	}
+	var docs []pkgDoc
+	var errs []error
+	for _, lp := range pkgs {
+		if len(lp.GoFiles) == 0 && lp.Err == nil {
+			// Only has _test.go files: nothing to document.
+			continue
+		}
+		if lp.Err != nil && !g.opts.TolerateErrors {
+			errs = append(errs, lp.Err)
+			continue
+		}
+		dir, err := goPackagesDir(lp.Package)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", lp.PkgPath, err))
+			continue
+		}
+		pg, err := g.withConfig(dir, moduleDir(lp.Package))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to load config: %w", lp.PkgPath, err))
+			continue
+		}
+		doc, err := pg.newDoc(lp)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to load package in dir %q: %w", lp.PkgPath, dir, err))
+			continue
+		}
+		if lp.Err != nil {
+			g.logf("Warning: %v\n", lp.Err)
+		}
+		if lp.TestErr != nil {
+			g.logf("Warning: ignoring the examples of %s: %v\n", lp.PkgPath, lp.TestErr)
+		}
+		docs = append(docs, pkgDoc{dir, doc, pg})
+	}
+
+	// Summarize each package for the index, which is either included in the
+	// README of the package in the index directory, or an index README on its
+	// own.
+	var root string
+	var rootDoc *Doc
+	var ig *Generator // of the index README, when not that of a package
+	var index []Package
+	if g.opts.Index {
+		if root, err = indexDir(args); err != nil {
+			return err
+		}
+		var rootGen *Generator
+		for i, pd := range docs {
+			if pd.dir == root {
+				rootDoc, rootGen = &docs[i].doc, pd.g
+			}
+		}
+		if rootDoc == nil {
+			if ig, err = g.withConfig(root, ""); err != nil {
+				return errors.Join(append(errs, fmt.Errorf("index: failed to load config: %w", err))...)
+			}
+			rootGen = ig
+		}
+
+		// Link to each README relative to the index README.
+		base := filepath.Dir(rootGen.readmePath(root))
+		for _, pd := range docs {
+			if pd.dir == root {
+				continue
+			}
+			rel, err := filepath.Rel(base, pd.g.readmePath(pd.dir))
+			if err != nil {
+				return err
+			}
+			index = append(index, pd.doc.Summary(filepath.ToSlash(rel)))
+		}
+		sort.Slice(index, func(i, j int) bool {
+			return index[i].ImportPath < index[j].ImportPath
+		})
+		if rootDoc != nil {
+			rootDoc.Packages = index
+		}
+	}
+
+	for i, pd := range docs {
+		if err := pd.g.generate(pd.dir, &docs[i].doc, pd.doc.Map(), builtinTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pd.doc.ImportPath, err))
+		}
+	}
+	if g.opts.Index && rootDoc == nil {
+		title := value(ig.opts.Title)
+		if title == "" {
+			title = filepath.Base(root)
+		}
+		docm := map[string]interface{}{
+			"Title":    title,
+			"Today":    time.Now().Format("2006.01.02"),
+			"Packages": index,
+		}
+		if err := ig.generate(root, nil, docm, builtinIndexTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("index: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Render renders the README of doc, using the template found in the package
+// directory dir, else the builtin template.
+func (g *Generator) Render(dir string, doc Doc) ([]byte, error) {
+	var modDir string
+	if doc.Module != nil {
+		modDir = doc.Module.Dir
+	}
+	pg, err := g.withConfig(dir, modDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return pg.render(dir, &doc, doc.Map(), builtinTemplate)
+}
+
+// render renders the README in dir of the package documented by d, or nil for
+// an index, using the template data docm, and the template found in dir, else
+// builtin.
+func (g *Generator) render(dir string, d *Doc, docm map[string]interface{}, builtin *template.Template) ([]byte, error) {
+	// Mark the docs whose headings are nested by nestDocHeadings below.
+	if d != nil && value(g.opts.NestHeadings) {
+		docm["Doc"] = markDoc(d.Doc)
+		docm["API"] = d.API.markDocs()
+	}
+
+	// Add additional fields to the template data
+	for _, d := range g.opts.Defs {
+		if err := setDef(docm, d); err != nil {
+			return nil, err
+		}
+	}
+
+	// Execute the template
+	tmpl, err := g.getTemplateOr(dir, builtin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+	tmpl.Funcs(templateFuncs(dir, d))
+
+	// The table of contents lists the headings of the README, which are only
+	// known once it is rendered: render it first without one, then again with
+	// that of the first rendering.  A table of contents has no headings of its
+	// own, so the headings of both renderings are the same.
+	var readme []byte
+	for pass := 0; pass < 2; pass++ {
+		docm["TOC"] = tableOfContents(string(readme), value(g.opts.TOCDepth))
+		tmpl.Funcs(template.FuncMap{"toc": tocFunc(string(readme), value(g.opts.TOCDepth))})
+		if readme, err = g.execute(dir, tmpl, docm); err != nil {
+			return nil, err
+		}
+		readme = []byte(nestDocHeadings(string(readme)))
+	}
+	return readme, nil
+}
+
+// execute executes tmpl with the template data docm, and returns the README of
+// the package in dir.  In marker mode, only the sections of the existing README
+// between markers are rendered.
+func (g *Generator) execute(dir string, tmpl *template.Template, docm map[string]interface{}) ([]byte, error) {
+	if g.opts.Markers {
+		nm := g.readmePath(dir)
+		readme, err := ioutil.ReadFile(nm)
+		if err != nil {
+			return nil, err
+		}
+		b, err := replaceMarkers(readme, func(name string) ([]byte, error) {
+			if tmpl.Lookup(name) == nil {
+				return nil, fmt.Errorf("no template %q", name)
+			}
+			var buf bytes.Buffer
+			if err := tmpl.ExecuteTemplate(&buf, name, docm); err != nil {
+				return nil, fmt.Errorf("failed to execute template: %w", err)
+			}
+			return buf.Bytes(), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", nm, err)
+		}
+		return b, nil
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, docm); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// generate renders the README in dir, as per render, and writes it to the
+// output path.  In check mode, it instead compares the rendered README with the
+// existing one.
+func (g *Generator) generate(dir string, d *Doc, docm map[string]interface{}, builtin *template.Template) error {
+	b, err := g.render(dir, d, docm, builtin)
+	if err != nil {
+		return err
+	}
+
+	nm := g.output()
+	if g.opts.Check {
+		diff, err := g.checkReadme(dir, b)
+		if err != nil {
+			return fmt.Errorf("failed to check %s for %q: %w", nm, dir, err)
+		}
+		if diff != "" {
+			if g.opts.Diff != nil {
+				fmt.Fprint(g.opts.Diff, diff)
+			}
+			return fmt.Errorf("%s for %q is out of date", nm, dir)
+		}
+		return nil
+	}
+
+	if value(g.opts.Output) == "-" {
+		if g.opts.Stdout != nil {
+			_, err = g.opts.Stdout.Write(b)
+		}
+		return err
+	}
+	if err := g.writeReadme(dir, b); err != nil {
+		return fmt.Errorf("failed to write %s for %q: %w", nm, dir, err)
+	}
+	return nil
+}
+
+// output returns the path of the README, relative to the package directory.
+// When writing to stdout, it is the default README.md that is read in check
+// and marker modes.
+func (g *Generator) output() string {
+	if out := value(g.opts.Output); out != "" && out != "-" {
+		return out
+	}
+	return "README.md"
+}
+
+// readmePath returns the path of the README of the package in dir.
+func (g *Generator) readmePath(dir string) string {
+	nm := filepath.FromSlash(g.output())
+	if filepath.IsAbs(nm) {
+		return nm
+	}
+	return filepath.Join(dir, nm)
+}
+
+// writeReadme writes the README of the package in dir, unless it exists and is
+// not to be overwritten.  It is written to a temporary file that is then
+// renamed, so that a failure never leaves a partially written README.
+func (g *Generator) writeReadme(dir string, b []byte) error {
+	nm := g.readmePath(dir)
+	perm := fs.FileMode(0644)
+	if fi, err := os.Stat(nm); err == nil {
+		if !g.opts.Force && !g.opts.Markers {
+			return fmt.Errorf("%s already exists at %s. Use -f to overwrite", g.output(), dir)
+		}
+		perm = fi.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(nm), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(nm), "."+filepath.Base(nm)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // if not renamed
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), nm)
+}
+
+// checkReadme compares the README of the package in dir with the generated
+// contents, returning a unified diff of the two, or "" if they are the same.
+func (g *Generator) checkReadme(dir string, generated []byte) (string, error) {
+	nm := g.readmePath(dir)
+	existing, err := ioutil.ReadFile(nm)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return unifiedDiff(nm, nm+" (generated)", existing, generated), nil
+}
+
+// loadArgs loads the packages named by the command-line arguments: either a
+// single package directory, or one or more package patterns such as "./...".
+func loadArgs(args []string) ([]*loadedPackage, error) {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	if len(args) == 1 {
+		if fi, err := os.Stat(args[0]); err == nil && fi.IsDir() {
+			dir, err := filepath.Abs(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return loadPackages(dir, docLoadMode, ".")
+		}
+	}
+
+	patterns := make([]string, len(args))
+	for i, arg := range args {
+		// Load directories as filesystem patterns, rather than import paths.
+		if !filepath.IsAbs(arg) && !strings.HasPrefix(arg, ".") {
+			if fi, err := os.Stat(arg); err == nil && fi.IsDir() {
+				arg = "./" + arg
+			}
+		}
+		patterns[i] = arg
+	}
+	return loadPackages("", docLoadMode, patterns...)
+}
+
+// indexDir returns the directory of the index README for the command-line
+// arguments: the package directory if one is given, else the working
+// directory.
+func indexDir(args []string) (string, error) {
+	if len(args) == 1 {
+		if fi, err := os.Stat(args[0]); err == nil && fi.IsDir() {
+			return filepath.Abs(args[0])
+		}
+	}
+	return os.Getwd()
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// goPackagesDir returns the directory of pkg.
+//
+// It relies on the fact that GoFiles are absolute paths.
+// See https://github.com/golang/go/issues/38445#issuecomment-732806052
+func goPackagesDir(pkg *packages.Package) (string, error) {
+	if len(pkg.GoFiles) == 0 {
+		return "", errors.New("package has no Go files")
+	}
+	return filepath.Dir(pkg.GoFiles[0]), nil
+}
+
+// A LoadError reports the errors found while loading a package, including
+// those of its dependencies, or of its test variants.
+type LoadError struct {
+	Pkg    string // import path of the package
+	Errors []packages.Error
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to load package %s:", e.Pkg)
+	for _, err := range e.Errors {
+		pos := err.Pos
+		if pos == "" {
+			pos = "-"
+		}
+		fmt.Fprintf(&b, "\n\t%s: %s error: %s", pos, errorKindString(err.Kind), err.Msg)
+	}
+	return b.String()
+}
+
+func errorKindString(k packages.ErrorKind) string {
+	switch k {
+	case packages.ListError:
+		return "list"
+	case packages.ParseError:
+		return "parse"
+	case packages.TypeError:
+		return "type"
+	}
+	return "unknown"
+}
+
+// loadError returns a *LoadError of the errors in pkgs, and their
+// dependencies, or nil if there are none.  It is named for the package with
+// import path pkgPath.
+func loadError(pkgPath string, pkgs ...*packages.Package) error {
+	var errs []packages.Error
+	seen := make(map[string]bool)
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, err := range p.Errors {
+			// The same error is reported by each of the test variants.
+			if key := err.Error(); !seen[key] {
+				seen[key] = true
+				errs = append(errs, err)
+			}
+		}
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+	return &LoadError{Pkg: pkgPath, Errors: errs}
+}
+
+// loadPackage loads the package in the given filesystem directory.
+func loadPackage(dir string, m packages.LoadMode) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: m,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package in dir %q: %w", dir, err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("could not find package: %s", dir)
+	}
+	if err := loadError(pkgs[0].PkgPath, pkgs[0]); err != nil {
+		return nil, err
+	}
+	return pkgs[0], nil
+}
+
+// A loadedPackage is a package together with its test variants: the package
+// compiled together with its internal _test.go files, and the external _test
+// package, if any.
+type loadedPackage struct {
+	*packages.Package
+	Tests   []*packages.Package // nil if the tests failed to load
+	Err     error               // a *LoadError, if the package failed to load
+	TestErr error               // a *LoadError, if only its tests failed to load
+}
+
+// loadPackages loads the packages matching the given patterns, relative to the
+// filesystem directory dir, along with their test variants.  All packages are
+// loaded with a single call to packages.Load, so that dependencies are shared.
+//
+// Errors found in each package are reported in its Err field, rather than
+// failing the entire load.  Errors found only in its test variants are
+// reported in its TestErr field instead, and the test variants are dropped, so
+// that a broken test only loses the examples of the package.
+func loadPackages(dir string, m packages.LoadMode, patterns ...string) ([]*loadedPackage, error) {
+	cfg := &packages.Config{
+		Mode:  m,
+		Dir:   dir,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages %q in dir %q: %w", patterns, dir, err)
+	}
+	var out []*loadedPackage
+	tests := make(map[string][]*packages.Package)
+	for _, p := range pkgs {
+		// Test variants have IDs of the form "path [forpath.test]".
+		switch {
+		case strings.HasSuffix(p.ID, ".test"):
+			// Synthesized test main package: ignore it.
+		case strings.HasSuffix(p.ID, ".test]"):
+			i := strings.LastIndex(p.ID, " [")
+			forTest := strings.TrimSuffix(p.ID[i+2:len(p.ID)-1], ".test")
+			tests[forTest] = append(tests[forTest], p)
+		default:
+			out = append(out, &loadedPackage{Package: p})
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("could not find packages %q in dir %q", patterns, dir)
+	}
+	for _, lp := range out {
+		lp.Err = loadError(lp.PkgPath, lp.Package)
+		lp.Tests = tests[lp.PkgPath]
+		if lp.Err != nil || len(lp.Tests) == 0 {
+			continue
+		}
+		// The errors of the package itself are also reported by its test
+		// variants, so these are only those of the tests.
+		if lp.TestErr = loadError(lp.PkgPath, lp.Tests...); lp.TestErr != nil {
+			lp.Tests = nil
+		}
+	}
+	return out, nil
+}
+
+// testFiles returns the syntax of the _test.go files of the given packages.
+func testFiles(pkgs []*packages.Package) []*ast.File {
+	var files []*ast.File
+	for _, p := range pkgs {
+		for _, f := range p.Syntax {
+			if strings.HasSuffix(p.Fset.File(f.Pos()).Name(), "_test.go") {
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
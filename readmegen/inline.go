@@ -0,0 +1,256 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"bytes"
+	"go/doc"
+	"go/doc/comment"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// plain prints the plain text s of a doc comment to out.  If escaping all, the
+// Markdown metacharacters of the text, including the "|" of tables and the "&"
+// of entities, are escaped as per go/doc/comment, so that it renders as godoc
+// does; see also escapeLineStart.  Otherwise, Markdown written in the text is
+// preserved, except for the metacharacters that would format the text by
+// accident, unless raw: runs of "*" or "_" within a word, "#" at the start of a
+// line, and "<" before a letter, "/" or "!".  When the renderer has anchors,
+// the exported identifiers of the package are linked to their API sections.
+func (p *markdownPrinter) plain(out *bytes.Buffer, s string) {
+	// Markdown may only be written in the text when not escaping all, and its
+	// accidents are only escaped when not raw.
+	md := !p.escapeAll
+	accidental := md && !p.r.rawMarkdown
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case p.escapeAll && strings.IndexByte("`_*[<\\|&", c) >= 0:
+			if c == '[' {
+				p.brackets++
+			}
+			out.WriteByte('\\')
+			out.WriteByte(c)
+			i++
+
+		case p.escapeAll && c == ']':
+			// Do not link the identifiers of unresolved doc links.
+			if p.brackets > 0 {
+				p.brackets--
+			}
+			out.WriteByte(c)
+			i++
+
+		case md && c == '`':
+			// Copy a code span verbatim.
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			fence := s[i : i+n]
+			if j := strings.Index(s[i+n:], fence); j >= 0 {
+				n += j + len(fence)
+			}
+			out.WriteString(s[i : i+n])
+			i += n
+
+		case md && p.dest:
+			// Copy the destination of a Markdown link verbatim.
+			out.WriteByte(c)
+			p.dest = c != ')'
+			i++
+
+		case md && c == '[':
+			p.brackets++
+			out.WriteByte(c)
+			i++
+
+		case md && c == ']':
+			if p.brackets > 0 {
+				p.brackets--
+			}
+			out.WriteByte(c)
+			i++
+			if i < len(s) && s[i] == '(' {
+				out.WriteByte('(')
+				p.dest = true
+				i++
+			}
+
+		case accidental && (c == '*' || c == '_'):
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], s[i:i+1]))
+			if isWordRune(lastRune(out)) && isWordRune(firstRune(s[i+n:])) {
+				for k := 0; k < n; k++ {
+					out.WriteByte('\\')
+					out.WriteByte(c)
+				}
+			} else {
+				out.WriteString(s[i : i+n])
+			}
+			i += n
+
+		case accidental && c == '#' && (out.Len() == 0 || lastRune(out) == '\n'):
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "#"))
+			if r := firstRune(s[i+n:]); n <= 6 && (r == 0 || r == ' ' || r == '\t' || r == '\n') {
+				out.WriteByte('\\')
+			}
+			out.WriteString(s[i : i+n])
+			i += n
+
+		case accidental && c == '<':
+			if r := firstRune(s[i+1:]); unicode.IsLetter(r) || r == '/' || r == '!' {
+				out.WriteByte('\\')
+			}
+			out.WriteByte(c)
+			i++
+
+		case p.r.anchors != nil && unicode.IsLetter(firstRune(s[i:])):
+			i += p.ident(out, s[i:])
+
+		default:
+			_, n := utf8.DecodeRuneInString(s[i:])
+			out.WriteString(s[i : i+n])
+			i += n
+		}
+	}
+}
+
+// escapeLineStart returns line with a backslash before what would otherwise
+// start a list, heading, block quote or code fence, or underline the previous
+// line as a setext heading, as per go/doc/comment.
+func escapeLineStart(line string) string {
+	if line == "" {
+		return line
+	}
+	switch c := line[0]; {
+	case c == '+' || c == '-' || c == '*' || c == '#' || c == '>':
+		return "\\" + line
+	case c == '=' && strings.TrimRight(line, "= \t") == "":
+		return "\\" + line
+	case c == '~' && strings.HasPrefix(line, "~~~"):
+		return "\\" + line
+	case '0' <= c && c <= '9':
+		n := len(line) - len(strings.TrimLeft(line, "0123456789"))
+		if n < len(line) && (line[n] == '.' || line[n] == ')') {
+			return line[:n] + "\\" + line[n:]
+		}
+	}
+	return line
+}
+
+// escapeLineStarts returns md with the start of each line escaped, as per
+// escapeLineStart.
+func escapeLineStarts(md string) string {
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		lines[i] = escapeLineStart(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newStyleDoc reports whether the doc comment d, parsed from text, uses the
+// syntax introduced in Go 1.19, rather than Markdown: "# Heading" headings,
+// indented lists or link definitions.  Doc links are not considered, as text
+// such as "[Name]" is common in older comments too.
+func newStyleDoc(d *comment.Doc, text string) bool {
+	if len(d.Links) > 0 {
+		return true
+	}
+	for _, b := range d.Content {
+		if _, ok := b.(*comment.List); ok {
+			return true
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			return true
+		}
+	}
+	return false
+}
+
+// ident prints the word at the start of s to out, linked to its API section if
+// it is an exported identifier of the package, such as "Name" or "Type.Method",
+// and returns its length.
+func (p *markdownPrinter) ident(out *bytes.Buffer, s string) int {
+	word := func(s string) int {
+		return len(s) - len(strings.TrimLeftFunc(s, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		}))
+	}
+	n := word(s)
+	full := n
+	if n+1 < len(s) && s[n] == '.' && unicode.IsLetter(firstRune(s[n+1:])) {
+		full = n + 1 + word(s[n+1:])
+	}
+
+	prev := lastRune(out)
+	linkable := p.brackets == 0 && p.noIdents == 0 && prev != '.' && !isWordRune(prev)
+	for _, m := range []int{full, n} {
+		if anchor, ok := p.r.anchors[s[:m]]; ok && linkable && s[:m] != p.self {
+			out.WriteString("[" + s[:m] + "](#" + anchor + ")")
+			return m
+		}
+	}
+	out.WriteString(s[:n])
+	return n
+}
+
+// apiAnchors returns the anchors of the API sections of the builtin template,
+// keyed by the names of the exported funcs and types of pkg, and of their
+// methods as "Type.Method".
+func apiAnchors(pkg *doc.Package) map[string]string {
+	anchors := make(map[string]string)
+	for _, f := range pkg.Funcs {
+		anchors[f.Name] = funcAnchor(f)
+	}
+	for _, t := range pkg.Types {
+		anchors[t.Name] = typeAnchor(t.Name)
+		for _, f := range t.Funcs {
+			anchors[f.Name] = funcAnchor(f)
+		}
+		for _, f := range t.Methods {
+			anchors[t.Name+"."+f.Name] = funcAnchor(f)
+		}
+	}
+	return anchors
+}
+
+// funcAnchor returns the anchor of the heading of the func f in the builtin
+// template, such as "func-name" or "func-t-type-name" for a method.
+func funcAnchor(f *doc.Func) string {
+	if f.Recv != "" {
+		return make(slugger).slug("func (" + f.Recv + ") " + f.Name)
+	}
+	return make(slugger).slug("func " + f.Name)
+}
+
+// typeAnchor returns the anchor of the heading of the type name in the builtin
+// template.
+func typeAnchor(name string) string {
+	return make(slugger).slug("type " + name)
+}
+
+// isWordRune reports whether r is part of a word, for the purpose of finding
+// intraword emphasis.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// firstRune returns the first rune of s, or 0 if s is empty.
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return 0
+	}
+	return r
+}
+
+// lastRune returns the last rune written to out, or 0 if there is none.
+func lastRune(out *bytes.Buffer) rune {
+	r, _ := utf8.DecodeLastRune(out.Bytes())
+	if r == utf8.RuneError {
+		return 0
+	}
+	return r
+}
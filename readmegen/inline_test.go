@@ -0,0 +1,48 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"go/doc"
+	"go/doc/comment"
+	"testing"
+)
+
+func TestEscapeLineStart(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"", ""},
+		{"text", "text"},
+		{"- item", "\\- item"},
+		{"+ item", "\\+ item"},
+		{"* item", "\\* item"},
+		{"# heading", "\\# heading"},
+		{"> quote", "\\> quote"},
+		{"1. item", "1\\. item"},
+		{"1) item", "1\\) item"},
+		{"1.22 or later", "1\\.22 or later"},
+		{"===", "\\==="},
+		{"= = ", "\\= = "},
+		{"=x", "=x"},
+		{"---", "\\---"},
+		{"~~~", "\\~~~"},
+		{"~x", "~x"},
+	}
+	for _, tt := range tests {
+		if got := escapeLineStart(tt.line); got != tt.want {
+			t.Errorf("escapeLineStart(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPackageDocStringEscapes(t *testing.T) {
+	// The heading makes it a Go 1.19 doc comment, whose text is escaped.
+	const text = "# Usage\n\nA | B\n--- | ---\n1 | 2\n\nTitle\n===\n\n> not a quote &amp; more.\n"
+	const want = "## Usage\n\nA \\| B\n\\--- \\| ---\n1 \\| 2\n\nTitle\n\\===\n\n\\> not a quote \\&amp; more.\n\n"
+	r := &docRenderer{parser: &comment.Parser{}}
+	if got := r.packageDocString(&doc.Package{Doc: text}); got != want {
+		t.Errorf("packageDocString(%q)\n got %q\nwant %q", text, got, want)
+	}
+}
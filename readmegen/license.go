@@ -0,0 +1,113 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/licensecheck"
+)
+
+// License is a license file of a package, classified by its license text.
+type License struct {
+	SPDX       string  // SPDX license identifier, e.g. "MIT", or "" if unknown
+	Path       string  // slash-separated path of the file, relative to the README
+	Confidence float64 // fraction of the file that matches the license text, from 0 to 1
+}
+
+// licenseFilePrefixes are the lowercase prefixes of the names of license files.
+var licenseFilePrefixes = []string{"license", "licence", "copying", "unlicense"}
+
+// detectLicenses returns the licenses found in the files of the package dir,
+// else of the root of its module, if any, with paths relative to readmeDir, the
+// directory of the README.  A file holding more than one license yields a
+// License for each.
+func detectLicenses(dir, readmeDir string, mod *Module) ([]License, error) {
+	dirs := []string{dir}
+	if mod != nil && mod.Dir != "" && mod.Dir != dir {
+		dirs = append(dirs, mod.Dir)
+	}
+	for _, ldir := range dirs {
+		files, err := licenseFiles(ldir)
+		if err != nil {
+			return nil, err
+		}
+		var licenses []License
+		for _, file := range files {
+			text, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			rel, err := filepath.Rel(readmeDir, file)
+			if err != nil {
+				return nil, err
+			}
+			licenses = append(licenses, classifyLicense(filepath.ToSlash(rel), text)...)
+		}
+		if len(licenses) > 0 {
+			return licenses, nil
+		}
+	}
+	return nil, nil
+}
+
+// licenseFiles returns the license files in dir, sorted by name.
+func licenseFiles(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, fi := range fis {
+		if fi.IsDir() || filepath.Ext(fi.Name()) == ".go" {
+			continue
+		}
+		name := strings.ToLower(fi.Name())
+		for _, prefix := range licenseFilePrefixes {
+			if strings.HasPrefix(name, prefix) {
+				files = append(files, filepath.Join(dir, fi.Name()))
+				break
+			}
+		}
+	}
+	return files, nil
+}
+
+// classifyLicense returns the licenses of the license file at path with the
+// given text, using the license texts bundled with licensecheck.  If the text
+// does not match any license, a single License with an empty SPDX is returned.
+func classifyLicense(path string, text []byte) []License {
+	cov := licensecheck.Scan(text)
+
+	// Share the coverage among the licenses matched, by the length of each
+	// match.
+	var ids []string
+	lens := make(map[string]int)
+	total := 0
+	for _, m := range cov.Match {
+		if m.IsURL {
+			continue
+		}
+		if _, ok := lens[m.ID]; !ok {
+			ids = append(ids, m.ID)
+		}
+		lens[m.ID] += m.End - m.Start
+		total += m.End - m.Start
+	}
+	if len(ids) == 0 {
+		return []License{{Path: path}}
+	}
+
+	licenses := make([]License, len(ids))
+	for i, id := range ids {
+		licenses[i] = License{
+			SPDX:       id,
+			Path:       path,
+			Confidence: cov.Percent / 100 * float64(lens[id]) / float64(total),
+		}
+	}
+	return licenses
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// regexpNestedItem matches the hierarchical number of a nested list item, such
+// as "1.1." or "1.2.3", at the start of a line.
+var regexpNestedItem = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)+)(\.?)[ \t]+`)
+
+// nestedItem parses line as starting with the hierarchical number of a nested
+// list item, such as "1.1.".  It returns the elements of the number, and the
+// length of the marker.  Unless dotless, the number must end with a dot, as
+// text such as "1.22 or later" otherwise starts with a version number.
+func nestedItem(line string, dotless bool) (nums []string, n int, ok bool) {
+	m := regexpNestedItem.FindStringSubmatch(line)
+	if m == nil || (m[2] == "" && !dotless) {
+		return nil, 0, false
+	}
+	return strings.Split(m[1], "."), len(m[0]), true
+}
+
+// A listFrame is an open item of a nested list, that encloses the items
+// that follow it until it is closed.
+type listFrame struct {
+	indent int // indentation of the marker of the item in the doc comment
+	col    int // column of the content of the item in the Markdown
+}
+
+// listFrames is the stack of open items of a nested list, innermost last.
+type listFrames []listFrame
+
+// push opens an item with the Markdown marker, whose marker is indented by
+// indent columns in the doc comment, within the innermost open item.  It
+// returns the indentation of the marker in the Markdown.
+func (fs *listFrames) push(indent int, marker string) string {
+	col := 0
+	if n := len(*fs); n > 0 {
+		col = (*fs)[n-1].col
+	}
+	*fs = append(*fs, listFrame{indent: indent, col: col + len(marker)})
+	return strings.Repeat(" ", col)
+}
+
+// popTo closes the innermost items, until at most n remain open.
+func (fs *listFrames) popTo(n int) {
+	if len(*fs) > n {
+		*fs = (*fs)[:n]
+	}
+}
+
+// popIndented closes the innermost items whose markers are indented by at
+// least indent columns in the doc comment, leaving at least min open.
+func (fs *listFrames) popIndented(indent, min int) {
+	for len(*fs) > min && (*fs)[len(*fs)-1].indent >= indent {
+		*fs = (*fs)[:len(*fs)-1]
+	}
+}
+
+// contentIndent returns the indentation of the content of the innermost item in
+// the doc comment, assuming that its marker is as wide as in the Markdown, or
+// 0 if there is none.
+func (fs listFrames) contentIndent() int {
+	n := len(fs)
+	if n == 0 {
+		return 0
+	}
+	parent := 0
+	if n > 1 {
+		parent = fs[n-2].col
+	}
+	return fs[n-1].indent + fs[n-1].col - parent
+}
+
+// pad returns the indentation of the content of the innermost item, of at
+// least min columns.
+func (fs listFrames) pad(min int) string {
+	col := min
+	if n := len(fs); n > 0 && fs[n-1].col > col {
+		col = fs[n-1].col
+	}
+	return strings.Repeat(" ", col)
+}
+
+// listMarker parses line as starting with a list marker, as per go/doc/comment.
+// It returns the number of the item, or "" for a bullet, and the rest of the
+// line.
+func listMarker(line string) (num, rest string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", "", false
+	}
+	if r, n := utf8.DecodeRuneInString(line); r == '•' || r == '*' || r == '+' || r == '-' {
+		num, rest = "", line[n:]
+	} else if '0' <= line[0] && line[0] <= '9' {
+		n := 1
+		for n < len(line) && '0' <= line[n] && line[n] <= '9' {
+			n++
+		}
+		if n >= len(line) || (line[n] != '.' && line[n] != ')') {
+			return "", "", false
+		}
+		num, rest = line[:n], line[n+1:]
+	} else {
+		return "", "", false
+	}
+	if rest == "" || (rest[0] != ' ' && rest[0] != '\t') || strings.TrimSpace(rest) == "" {
+		return "", "", false
+	}
+	return num, strings.TrimSpace(rest), true
+}
+
+// indentWidth returns the width of the indentation of line, with tab stops
+// every 4 columns.
+func indentWidth(line string) int {
+	n := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 4 - n%4
+		default:
+			return n
+		}
+	}
+	return n
+}
@@ -0,0 +1,301 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A heading is an ATX heading of a Markdown document, such as "## Text".
+type heading struct {
+	Level int    // 1 to 6
+	Text  string // text of the heading, without the leading #s
+	Line  int    // index of the line of the heading
+}
+
+// mdLines calls fn for each line of the Markdown md, along with its index, and
+// whether it is within a fenced code block, including the fences themselves.
+func mdLines(md string, fn func(i int, line string, inCode bool)) {
+	var fence string
+	for i, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			fn(i, line, true)
+			if strings.HasPrefix(trimmed, fence) && strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if f := codeFenceOf(trimmed); f != "" && len(line)-len(trimmed) < 4 {
+			fence = f
+			fn(i, line, true)
+			continue
+		}
+		fn(i, line, false)
+	}
+}
+
+// codeFenceOf returns the fence that opens a fenced code block at the start of
+// line, such as "```", or "" if there is none.
+func codeFenceOf(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return strings.Repeat(c, n)
+		}
+	}
+	return ""
+}
+
+// parseHeading returns the heading of line, if it is an ATX heading.
+func parseHeading(line string) (level int, text string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) >= 4 {
+		return 0, "", false // indented code
+	}
+	level = len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if level < 1 || level > 6 {
+		return 0, "", false
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	text = strings.TrimSpace(rest)
+	// Strip an optional closing sequence of #s.
+	if t := strings.TrimRight(text, "#"); t != text && (t == "" || strings.HasSuffix(t, " ")) {
+		text = strings.TrimSpace(t)
+	}
+	return level, text, true
+}
+
+// markdownHeadings returns the ATX headings of the Markdown md, excluding those
+// within fenced code blocks.
+func markdownHeadings(md string) []heading {
+	var hs []heading
+	mdLines(md, func(i int, line string, inCode bool) {
+		if inCode {
+			return
+		}
+		if level, text, ok := parseHeading(line); ok {
+			hs = append(hs, heading{Level: level, Text: text, Line: i})
+		}
+	})
+	return hs
+}
+
+// shiftHeadings returns the Markdown md with the level of each ATX heading,
+// outside of fenced code blocks, shifted by n, and clamped to levels 1 to 6.
+func shiftHeadings(n int, md string) string {
+	if n == 0 {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+	for _, h := range markdownHeadings(md) {
+		level := h.Level + n
+		if level < 1 {
+			level = 1
+		} else if level > 6 {
+			level = 6
+		}
+		lines[h.Line] = strings.Repeat("#", level) + " " + h.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// The start and end of rendered docs in a README, whose headings are nested by
+// nestDocHeadings.  They are runes of the private use area, so that they pass
+// through template functions such as mdEscape.
+const (
+	docStart = "\uE000"
+	docEnd   = "\uE001"
+)
+
+// markDoc returns the rendered doc md, marked as such for nestDocHeadings, or
+// "" if md is empty.
+func markDoc(md string) string {
+	if md == "" {
+		return md
+	}
+	return docStart + "\n" + md + docEnd
+}
+
+// nestDocHeadings returns the README md, with the headings of each marked doc
+// shifted so that the shallowest is one level below the heading of the README
+// that encloses the doc, and the marks removed.  The headings of a doc without
+// an enclosing heading are left as is.
+func nestDocHeadings(md string) string {
+	if !strings.Contains(md, docStart) {
+		return md
+	}
+	lines := strings.Split(md, "\n")
+
+	// Find the lines of each doc, from the line of its start to that of its
+	// end.
+	type span struct{ start, end int }
+	var docs []span
+	for i, line := range lines {
+		if strings.Contains(line, docStart) {
+			docs = append(docs, span{i, len(lines) - 1})
+		}
+		if strings.Contains(line, docEnd) && len(docs) > 0 {
+			docs[len(docs)-1].end = i
+		}
+	}
+
+	hs := markdownHeadings(md)
+	for _, doc := range docs {
+		enclosing, minLevel := 0, 7
+		for _, h := range hs {
+			within := false
+			for _, d := range docs {
+				within = within || d.start <= h.Line && h.Line <= d.end
+			}
+			switch {
+			case h.Line < doc.start && !within:
+				enclosing = h.Level
+			case doc.start <= h.Line && h.Line <= doc.end && h.Level < minLevel:
+				minLevel = h.Level
+			}
+		}
+		if enclosing == 0 || minLevel == 7 {
+			continue
+		}
+		n := enclosing + 1 - minLevel
+		for _, h := range hs {
+			if doc.start <= h.Line && h.Line <= doc.end {
+				lines[h.Line] = shiftHeadings(n, lines[h.Line])
+			}
+		}
+	}
+
+	md = strings.Join(lines, "\n")
+	md = strings.ReplaceAll(md, docStart+"\n", "")
+	md = strings.ReplaceAll(md, docStart, "")
+	return strings.ReplaceAll(md, docEnd, "")
+}
+
+// A slugger returns the GitHub anchors of headings, which are unique within a
+// document.
+type slugger map[string]int
+
+// slug returns the anchor of the heading text, as GitHub renders it: lowercase,
+// without punctuation, with spaces replaced by hyphens, and suffixed by "-1",
+// "-2", etc. if already used.
+func (s slugger) slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(markdownText(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '_', r == '-':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	slug := b.String()
+	n := s[slug]
+	s[slug] = n + 1
+	if n > 0 {
+		slug = fmt.Sprintf("%s-%d", slug, n)
+	}
+	return slug
+}
+
+// markdownText returns the inline Markdown md as plain text, without links,
+// emphasis or code spans.
+func markdownText(md string) string {
+	var b strings.Builder
+	for i := 0; i < len(md); i++ {
+		switch c := md[i]; c {
+		case '\\':
+			if i+1 < len(md) {
+				i++
+				b.WriteByte(md[i])
+			}
+		case '*', '`':
+		case '!':
+			if i+1 >= len(md) || md[i+1] != '[' {
+				b.WriteByte(c)
+			}
+		case '[':
+		case ']':
+			// Skip the destination of a link.
+			if i+1 < len(md) && md[i+1] == '(' {
+				if j := strings.IndexByte(md[i:], ')'); j > 0 {
+					i += j
+				}
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// tableOfContents returns a nested list of links to the headings of md, of the
+// first depth levels of its headings, including the top level, or all levels
+// if depth is 0.  For example, a depth of 1 only lists the top level.
+func tableOfContents(md string, depth int) string {
+	hs := markdownHeadings(md)
+	minLevel := 7
+	for _, h := range hs {
+		if h.Level < minLevel {
+			minLevel = h.Level
+		}
+	}
+
+	var b strings.Builder
+	s := make(slugger)
+	var levels []int // of the enclosing items of the list
+	for _, h := range hs {
+		anchor := s.slug(h.Text)
+		if depth > 0 && h.Level-minLevel >= depth {
+			continue
+		}
+		// Nest within the items of lower levels only, so that a heading that
+		// skips levels is not indented by more than one.
+		for len(levels) > 0 && levels[len(levels)-1] >= h.Level {
+			levels = levels[:len(levels)-1]
+		}
+		fmt.Fprintf(&b, "%s- [%s](#%s)\n", strings.Repeat("  ", len(levels)), markdownText(h.Text), anchor)
+		levels = append(levels, h.Level)
+	}
+	return b.String()
+}
+
+// mdEscape returns s with the Markdown metacharacters escaped by backslashes,
+// so that it is rendered literally.
+func mdEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\`*_[]<>#|~", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// codeFence returns code as a fenced code block in the given language, using a
+// fence longer than any run of backticks in code.
+func codeFence(lang, code string) string {
+	n, run := 3, 0
+	for _, c := range code {
+		if c == '`' {
+			run++
+			if run >= n {
+				n = run + 1
+			}
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", n)
+	if !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+	return fence + lang + "\n" + code + fence
+}
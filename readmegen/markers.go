@@ -0,0 +1,93 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// regexpMarker matches the start and end markers of a generated section of a
+// README, such as:
+//
+//	<!-- godoc-readme-gen:start api -->
+//	<!-- godoc-readme-gen:end api -->
+//
+// The name of an end marker is optional.
+var regexpMarker = regexp.MustCompile(`<!--\s*godoc-readme-gen:(start|end)(?:\s+([\w.-]+))?\s*-->`)
+
+// replaceMarkers returns the README readme, with the contents between each pair
+// of start and end markers replaced by the rendering of the template named by
+// the start marker, as returned by render.  Everything outside of the markers
+// is left intact, and markers within fenced code blocks, such as those that
+// document the markers, are ignored.
+func replaceMarkers(readme []byte, render func(name string) ([]byte, error)) ([]byte, error) {
+	var inCode []bool // whether each line is within a fenced code block
+	mdLines(string(readme), func(_ int, _ string, code bool) {
+		inCode = append(inCode, code)
+	})
+
+	var out bytes.Buffer
+	var start []int // the start marker, if within a section
+	last := 0
+	for _, m := range regexpMarker.FindAllSubmatchIndex(readme, -1) {
+		line := 1 + bytes.Count(readme[:m[0]], []byte("\n"))
+		if inCode[line-1] {
+			continue
+		}
+		kind := string(readme[m[2]:m[3]])
+		var name string
+		if m[4] >= 0 {
+			name = string(readme[m[4]:m[5]])
+		}
+
+		switch {
+		case kind == "start" && start != nil:
+			return nil, fmt.Errorf("line %d: start marker within section %q", line, readme[start[4]:start[5]])
+		case kind == "start" && name == "":
+			return nil, fmt.Errorf("line %d: start marker without a name", line)
+		case kind == "start":
+			start = m
+		case start == nil:
+			return nil, fmt.Errorf("line %d: end marker without a start marker", line)
+		case name != "" && name != string(readme[start[4]:start[5]]):
+			return nil, fmt.Errorf("line %d: end marker %q does not match start marker %q", line, name, readme[start[4]:start[5]])
+		default:
+			section, err := render(string(readme[start[4]:start[5]]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", 1+bytes.Count(readme[:start[0]], []byte("\n")), err)
+			}
+			// Keep the rest of the line of the start marker, and the start of
+			// the line of the end marker, such as its indentation, unless
+			// both markers are on the same line.
+			from, to := start[1], m[0]
+			if i := bytes.IndexByte(readme[start[1]:m[0]], '\n'); i >= 0 {
+				from = start[1] + i + 1
+				to = bytes.LastIndexByte(readme[:m[0]], '\n') + 1
+			}
+			eol := []byte("\n")
+			if bytes.HasSuffix(readme[:from], []byte("\r\n")) {
+				eol = []byte("\r\n")
+			}
+			out.Write(readme[last:from])
+			if from == start[1] {
+				out.Write(eol)
+			}
+			section = bytes.ReplaceAll(section, []byte("\r\n"), []byte("\n"))
+			section = bytes.ReplaceAll(bytes.Trim(section, "\n"), []byte("\n"), eol)
+			if len(section) > 0 {
+				out.Write(section)
+				out.Write(eol)
+			}
+			last = to
+			start = nil
+		}
+	}
+	if start != nil {
+		return nil, fmt.Errorf("line %d: start marker %q without an end marker", 1+bytes.Count(readme[:start[0]], []byte("\n")), readme[start[4]:start[5]])
+	}
+	out.Write(readme[last:])
+	return out.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import "testing"
+
+func TestReplaceMarkers(t *testing.T) {
+	tests := []struct {
+		name, readme, want string
+	}{
+		{
+			name:   "section",
+			readme: "# T\n<!-- godoc-readme-gen:start x -->\nold\n<!-- godoc-readme-gen:end x -->\ntail\n",
+			want:   "# T\n<!-- godoc-readme-gen:start x -->\nA\nB\n<!-- godoc-readme-gen:end x -->\ntail\n",
+		},
+		{
+			name:   "CRLF",
+			readme: "# T\r\n<!-- godoc-readme-gen:start x -->\r\nold\r\n<!-- godoc-readme-gen:end -->\r\ntail\r\n",
+			want:   "# T\r\n<!-- godoc-readme-gen:start x -->\r\nA\r\nB\r\n<!-- godoc-readme-gen:end -->\r\ntail\r\n",
+		},
+		{
+			name:   "indented markers",
+			readme: "<details>\n  <!-- godoc-readme-gen:start x --> keep\n  old\n  <!-- godoc-readme-gen:end -->\n</details>\n",
+			want:   "<details>\n  <!-- godoc-readme-gen:start x --> keep\nA\nB\n  <!-- godoc-readme-gen:end -->\n</details>\n",
+		},
+		{
+			name:   "same line",
+			readme: "<!-- godoc-readme-gen:start x --><!-- godoc-readme-gen:end -->\n",
+			want:   "<!-- godoc-readme-gen:start x -->\nA\nB\n<!-- godoc-readme-gen:end -->\n",
+		},
+		{
+			name:   "fenced markers",
+			readme: "```\n<!-- godoc-readme-gen:start x -->\n<!-- godoc-readme-gen:end -->\n```\n",
+			want:   "```\n<!-- godoc-readme-gen:start x -->\n<!-- godoc-readme-gen:end -->\n```\n",
+		},
+	}
+	render := func(name string) ([]byte, error) {
+		return []byte("\nA\nB\n"), nil
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := replaceMarkers([]byte(tt.readme), render)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("replaceMarkers(%q)\n got %q\nwant %q", tt.readme, got, tt.want)
+			}
+			// Replacing the markers again leaves the README unchanged.
+			again, err := replaceMarkers(got, render)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(got) {
+				t.Errorf("replaceMarkers is not idempotent:\n got %q\nwant %q", again, got)
+			}
+		})
+	}
+}
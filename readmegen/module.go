@@ -0,0 +1,90 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+// Module is the Go module that contains a package.
+type Module struct {
+	Path      string    // module path
+	Version   string    // module version, or "" for the main module
+	Dir       string    // directory holding the module files
+	GoVersion string    // minimum Go version, from the go directive
+	Requires  []Require // requirements, from the go.mod file
+}
+
+// Require is a requirement of a module, from its go.mod file.
+type Require struct {
+	Path     string
+	Version  string
+	Indirect bool // true when marked "// indirect"
+}
+
+// newModule returns the module of pkg, or nil if pkg is not in a module.  The
+// package must have been loaded with packages.NeedModule.
+func newModule(pkg *packages.Package) (*Module, error) {
+	m := pkg.Module
+	if m == nil {
+		return nil, nil
+	}
+	mod := &Module{
+		Path:    m.Path,
+		Version: m.Version,
+	}
+	if m.Replace != nil {
+		// The files of the module are found in its replacement.
+		m = m.Replace
+	}
+	mod.Dir = m.Dir
+	mod.GoVersion = m.GoVersion
+	if m.GoMod == "" {
+		return mod, nil
+	}
+
+	bs, err := ioutil.ReadFile(m.GoMod)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.ParseLax(m.GoMod, bs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	for _, r := range f.Require {
+		mod.Requires = append(mod.Requires, Require{
+			Path:     r.Mod.Path,
+			Version:  r.Mod.Version,
+			Indirect: r.Indirect,
+		})
+	}
+	return mod, nil
+}
+
+// moduleDir returns the root directory of the module of pkg, or "" if pkg is
+// not in a module.
+func moduleDir(pkg *packages.Package) string {
+	m := pkg.Module
+	if m == nil {
+		return ""
+	}
+	if m.Replace != nil {
+		m = m.Replace
+	}
+	return m.Dir
+}
+
+// relPath returns the path of the package with import path importPath,
+// relative to the root of mod, or "" for the module root.
+func relPath(mod *Module, importPath string) string {
+	if mod == nil || importPath == mod.Path {
+		return ""
+	}
+	return strings.TrimPrefix(importPath, mod.Path+"/")
+}
@@ -0,0 +1,303 @@
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"bufio"
+	"go/ast"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Repo is the source repository of a package, detected without network access
+// from the local git remote, or otherwise from the import path.
+type Repo struct {
+	Host          string // host name, e.g. "github.com"
+	Forge         string // forge name, e.g. "github", or "" if unknown
+	Owner         string // owner of the repository, including any subgroups
+	Name          string // name of the repository
+	DefaultBranch string // default branch, if known
+	WebURL        string // web page of the repository
+	Dir           string // root directory of the local working tree, if any
+
+	forge Forge
+}
+
+// FileURL returns the web page of the file at the slash-separated path,
+// relative to the root of the repository, on the default branch.  It returns ""
+// if the forge is unknown.
+func (r *Repo) FileURL(path string) string {
+	if r.forge == nil {
+		return ""
+	}
+	branch := r.DefaultBranch
+	if branch == "" {
+		branch = "HEAD"
+	}
+	return r.forge.FileURL(r, branch, strings.TrimPrefix(path, "/"))
+}
+
+// A Forge is a source code hosting service, such as GitHub.
+type Forge interface {
+	// Name returns the name of the forge, e.g. "github".
+	Name() string
+	// Match reports whether host serves repositories of the forge.
+	Match(host string) bool
+	// WebURL returns the web page of the repository r.
+	WebURL(r *Repo) string
+	// FileURL returns the web page of the file at the slash-separated path
+	// relative to the root of the repository r, on branch.
+	FileURL(r *Repo, branch, path string) string
+}
+
+// forges are the registered forges, in order of precedence.
+var forges = []Forge{
+	urlForge{"github", []string{"github.com"}, "github", "/blob/"},
+	urlForge{"gitlab", []string{"gitlab.com"}, "gitlab", "/-/blob/"},
+	urlForge{"bitbucket", []string{"bitbucket.org"}, "bitbucket", "/src/"},
+	urlForge{"gitea", []string{"codeberg.org", "gitea.com"}, "gitea", "/src/branch/"},
+}
+
+// RegisterForge registers the forge f for the detection of repositories.  It
+// takes precedence over the forges registered before it, including the
+// builtin GitHub, GitLab, Bitbucket and Gitea forges.
+func RegisterForge(f Forge) {
+	forges = append([]Forge{f}, forges...)
+}
+
+// findForge returns the forge serving host, or nil if unknown.
+func findForge(host string) Forge {
+	for _, f := range forges {
+		if f.Match(host) {
+			return f
+		}
+	}
+	return nil
+}
+
+// A urlForge is a forge with conventional URLs for repositories and files.
+type urlForge struct {
+	name   string
+	hosts  []string // public hosts
+	hint   string   // self-hosted instances usually have this in their host name
+	blobID string   // path element before the branch of a file URL
+}
+
+func (f urlForge) Name() string {
+	return f.name
+}
+
+func (f urlForge) Match(host string) bool {
+	for _, h := range f.hosts {
+		if host == h {
+			return true
+		}
+	}
+	return strings.Contains(host, f.hint)
+}
+
+func (f urlForge) WebURL(r *Repo) string {
+	return "https://" + path.Join(r.Host, r.Owner, r.Name)
+}
+
+func (f urlForge) FileURL(r *Repo, branch, p string) string {
+	return f.WebURL(r) + f.blobID + path.Join(branch, p)
+}
+
+// detectRepo returns the repository of the package in dir, with the given
+// files and import path.  It returns nil if the repository is not found.
+func detectRepo(dir string, files []*ast.File, importPath string) *Repo {
+	var r *Repo
+	if gitDir, root := findGitDir(dir); gitDir != "" {
+		if remote := gitRemoteURL(gitDir); remote != "" {
+			r = parseRemoteURL(remote)
+		}
+		if r != nil {
+			r.Dir = root
+			r.DefaultBranch = gitDefaultBranch(gitDir)
+		}
+	}
+	if r == nil {
+		// Without a remote, try the canonical import path of the package
+		if p := importComment(files); p != "" {
+			importPath = p
+		}
+		r = parseImportPath(importPath)
+	}
+	if r == nil {
+		return nil
+	}
+
+	if r.forge = findForge(r.Host); r.forge != nil {
+		r.Forge = r.forge.Name()
+		r.WebURL = r.forge.WebURL(r)
+	} else {
+		r.WebURL = "https://" + path.Join(r.Host, r.Owner, r.Name)
+	}
+	return r
+}
+
+// findGitDir returns the git directory of the working tree containing dir,
+// along with the root of the working tree, or "" if there is none.
+func findGitDir(dir string) (gitDir, root string) {
+	for {
+		p := filepath.Join(dir, ".git")
+		if fi, err := os.Stat(p); err == nil {
+			if fi.IsDir() {
+				return p, dir
+			}
+			// A worktree or submodule: the file refers to the git directory.
+			if bs, err := ioutil.ReadFile(p); err == nil {
+				s := strings.TrimSpace(string(bs))
+				if strings.HasPrefix(s, "gitdir: ") {
+					gd := strings.TrimPrefix(s, "gitdir: ")
+					if !filepath.IsAbs(gd) {
+						gd = filepath.Join(dir, gd)
+					}
+					return gd, dir
+				}
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// gitCommonDir returns the directory shared by all worktrees of gitDir, that
+// holds the config and remote refs.
+func gitCommonDir(gitDir string) string {
+	bs, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	dir := strings.TrimSpace(string(bs))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return dir
+}
+
+// gitRemoteURL returns the URL of the "origin" remote, else of the first remote
+// found in the config of gitDir, or "" if there is none.
+func gitRemoteURL(gitDir string) string {
+	f, err := os.Open(filepath.Join(gitCommonDir(gitDir), "config"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var section, first string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case strings.HasPrefix(line, "["):
+			section = strings.Trim(line, "[]")
+		case strings.HasPrefix(section, "remote "):
+			k, v, ok := strings.Cut(line, "=")
+			if !ok || strings.TrimSpace(k) != "url" {
+				continue
+			}
+			v = strings.TrimSpace(v)
+			if name, err := strconv.Unquote(strings.TrimPrefix(section, "remote ")); err == nil && name == "origin" {
+				return v
+			}
+			if first == "" {
+				first = v
+			}
+		}
+	}
+	return first
+}
+
+// gitDefaultBranch returns the default branch of the origin remote of gitDir,
+// or "" if unknown.  The branch checked out is not a fallback, as it is often a
+// topic branch.
+func gitDefaultBranch(gitDir string) string {
+	bs, err := ioutil.ReadFile(filepath.Join(gitCommonDir(gitDir), "refs", "remotes", "origin", "HEAD"))
+	if err != nil {
+		return ""
+	}
+	const prefix = "ref: refs/remotes/origin/"
+	if ref := strings.TrimSpace(string(bs)); strings.HasPrefix(ref, prefix) {
+		return strings.TrimPrefix(ref, prefix)
+	}
+	return ""
+}
+
+// parseRemoteURL returns the repository of a git remote URL, in either the URL
+// form "https://host/owner/name.git" or the scp-like form
+// "git@host:owner/name.git", or nil if it is not understood.
+func parseRemoteURL(remote string) *Repo {
+	var host, p string
+	if i := strings.Index(remote, "://"); i < 0 {
+		// scp-like syntax: [user@]host:path
+		at := strings.Index(remote, "@")
+		colon := strings.Index(remote, ":")
+		if colon < 0 || colon < at {
+			return nil
+		}
+		host, p = remote[at+1:colon], remote[colon+1:]
+	} else {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return nil
+		}
+		host, p = u.Hostname(), u.Path
+	}
+	return newRepo(host, strings.TrimSuffix(strings.Trim(p, "/"), ".git"))
+}
+
+// parseImportPath returns the repository of an import path on a known forge,
+// or nil if it is not.
+func parseImportPath(importPath string) *Repo {
+	elems := strings.Split(importPath, "/")
+	if len(elems) < 3 || findForge(elems[0]) == nil {
+		return nil
+	}
+	return newRepo(elems[0], path.Join(elems[1:3]...))
+}
+
+// newRepo returns the repository at the slash-separated path p on host.
+func newRepo(host, p string) *Repo {
+	i := strings.LastIndex(p, "/")
+	if host == "" || i <= 0 || i == len(p)-1 {
+		return nil
+	}
+	return &Repo{
+		Host:  host,
+		Owner: p[:i],
+		Name:  p[i+1:],
+	}
+}
+
+// importComment returns the import path given by the import comment of the
+// package clause, e.g. package foo // import "example.com/foo", or "" if there
+// is none.
+func importComment(files []*ast.File) string {
+	for _, f := range files {
+		for _, g := range f.Comments {
+			if g.Pos() < f.Name.End() {
+				continue
+			}
+			c := g.List[0]
+			if !strings.HasPrefix(c.Text, "// import ") {
+				break // only the comment following the package clause
+			}
+			if p, err := strconv.Unquote(strings.TrimSpace(strings.TrimPrefix(c.Text, "// import "))); err == nil {
+				return p
+			}
+			break
+		}
+	}
+	return ""
+}
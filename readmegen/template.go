@@ -0,0 +1,309 @@
+// Copyright 2013 James Frasche. All rights reserved.
+// Copyright 2021 John Papandriopoulos.
+// Use of this code is governed by a BSD-License found in the LICENSE file.
+
+package readmegen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateString is the builtin template of a package README.  Each of its
+// sections is a block, that may be redefined by a user template or partial.
+var templateString = `{{block "header" . -}}
+<!-- DO NOT EDIT. -->
+<!-- Automatically generated with https://go.jpap.org/godoc-readme-gen -->
+
+# {{.Title}}{{block "badges" .}}{{range .Badges}} {{.Markdown}}{{end}}{{end}}
+
+{{end -}}
+{{block "install" . -}}
+{{if .Commands -}}
+# Install
+
+$CODEBLOCKshell
+{{range $cmdPath := .Commands -}}
+go install {{$cmdPath}}
+{{end -}}
+$CODEBLOCK
+{{end -}}
+{{end -}}
+{{block "import" . -}}
+{{if .Library -}}
+# Import
+
+$CODEBLOCKgo
+import "{{.ImportPath}}"
+$CODEBLOCK
+{{end -}}
+{{end -}}
+{{block "overview" . -}}
+# Overview
+
+{{.Doc}}
+
+{{end -}}
+{{block "packages" . -}}
+{{if .Packages -}}
+$PACKAGES
+{{end -}}
+{{end -}}
+{{block "examples" . -}}
+{{if .PackageExamples -}}
+# Examples
+{{range .PackageExamples}}
+## Example{{with .Suffix}} ({{.}}){{end}}
+
+{{.Code}}{{with .Output}}
+{{.}}{{end}}{{end}}
+{{end -}}
+{{end -}}
+{{block "api" . -}}
+{{if and .Library (not .API.Empty) -}}
+# API
+{{range .API.Consts}}
+$CODEBLOCKgo
+{{.Decl}}
+$CODEBLOCK
+{{with .Doc}}
+{{.}}
+{{end -}}
+{{end -}}
+{{range .API.Vars}}
+$CODEBLOCKgo
+{{.Decl}}
+$CODEBLOCK
+{{with .Doc}}
+{{.}}
+{{end -}}
+{{end -}}
+{{range .API.Funcs}}
+## func {{.Name}}
+
+$CODEBLOCKgo
+{{.Decl}}
+$CODEBLOCK
+{{with .Doc}}
+{{.}}
+{{end -}}
+{{range .Examples}}
+### Example{{with .Suffix}} ({{.}}){{end}}
+
+{{.Code}}{{with .Output}}
+{{.}}{{end}}{{end -}}
+{{end -}}
+{{range .API.Types}}
+## type {{.Name}}
+
+$CODEBLOCKgo
+{{.Decl}}
+$CODEBLOCK
+{{with .Doc}}
+{{.}}
+{{end -}}
+{{range .Examples}}
+### Example{{with .Suffix}} ({{.}}){{end}}
+
+{{.Code}}{{with .Output}}
+{{.}}{{end}}{{end -}}
+{{range .Consts}}
+$CODEBLOCKgo
+{{.Decl}}
+$CODEBLOCK
+{{with .Doc}}
+{{.}}
+{{end -}}
+{{end -}}
+{{range .Vars}}
+$CODEBLOCKgo
+{{.Decl}}
+$CODEBLOCK
+{{with .Doc}}
+{{.}}
+{{end -}}
+{{end -}}
+{{range .Funcs}}
+### func {{.Name}}
+
+$CODEBLOCKgo
+{{.Decl}}
+$CODEBLOCK
+{{with .Doc}}
+{{.}}
+{{end -}}
+{{range .Examples}}
+#### Example{{with .Suffix}} ({{.}}){{end}}
+
+{{.Code}}{{with .Output}}
+{{.}}{{end}}{{end -}}
+{{end -}}
+{{range .Methods}}
+### func ({{.Recv}}) {{.Name}}
+
+$CODEBLOCKgo
+{{.Decl}}
+$CODEBLOCK
+{{with .Doc}}
+{{.}}
+{{end -}}
+{{range .Examples}}
+#### Example{{with .Suffix}} ({{.}}){{end}}
+
+{{.Code}}{{with .Output}}
+{{.}}{{end}}{{end -}}
+{{end -}}
+{{end -}}
+{{end -}}
+{{end -}}
+{{block "bugs" . -}}
+{{if .Bugs -}}
+# Bugs
+
+{{range .Bugs}}* {{.}}{{end}}
+
+{{end -}}
+{{end -}}
+{{block "license" . -}}
+{{with .Licenses -}}
+# License
+
+{{range .}}- {{if .SPDX}}{{.SPDX}}{{else}}See{{end}}: [{{.Path}}]({{.Path}})
+{{end}}
+{{end -}}
+{{end -}}
+`
+
+// indexTemplateString is the builtin template of an index README, that links
+// to the READMEs of each package.
+var indexTemplateString = `{{block "header" . -}}
+<!-- DO NOT EDIT. -->
+<!-- Automatically generated with https://go.jpap.org/godoc-readme-gen -->
+
+# {{.Title}}
+
+{{end -}}
+{{block "packages" . -}}
+$PACKAGES
+{{- end}}`
+
+// packagesString is the table of packages shared by the builtin templates.
+var packagesString = `# Packages
+
+| Package | Synopsis | Kind |
+| --- | --- | --- |
+{{range .Packages -}}
+| [{{.ImportPath}}]({{.Readme}}) | {{.Synopsis}} | {{if .IsLibrary}}library{{else}}command{{end}} |
+{{end}}`
+
+var (
+	builtinTemplate      *template.Template
+	builtinIndexTemplate *template.Template
+)
+
+func init() {
+	// Backticks aren't allowed in a string literal...
+	templateString = strings.ReplaceAll(templateString, "$PACKAGES", packagesString)
+	templateString = strings.ReplaceAll(templateString, "$CODEBLOCK", "```")
+	builtinTemplate = template.Must(template.New("").Funcs(templateFuncs("", nil)).Parse(templateString))
+
+	indexTemplateString = strings.ReplaceAll(indexTemplateString, "$PACKAGES", packagesString)
+	builtinIndexTemplate = template.Must(template.New("").Funcs(templateFuncs("", nil)).Parse(indexTemplateString))
+}
+
+// BuiltinTemplate returns the text of the builtin template, headed by a
+// comment that documents the template functions.
+func BuiltinTemplate() string {
+	return funcsHelp + templateString
+}
+
+// PartialsDir is the directory of partial templates, found in the package
+// directory.
+const PartialsDir = ".readme"
+
+// getTemplateOr returns the template given by the Template option, relative to
+// dir, or the builtin template if there is none.  The blocks of the builtin
+// template may be redefined by the partials in PartialsDir, and by the given
+// template, which uses the builtin template if it has no content of its own.
+func (g *Generator) getTemplateOr(dir string, builtin *template.Template) (*template.Template, error) {
+	tmpl, err := builtin.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if err := parsePartials(tmpl, filepath.Join(dir, PartialsDir)); err != nil {
+		return nil, err
+	}
+
+	name := DefaultTemplateFile
+	if g.opts.Template != nil {
+		name = *g.opts.Template
+	}
+	if name == "" {
+		// Use the built-in template.
+		return tmpl, nil
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		// File does not exist.  If it's the default name, use the built-in
+		// template, otherwise return an error.
+		if name == DefaultTemplateFile {
+			return tmpl, nil
+		}
+		return nil, fmt.Errorf("failed to open template file: %s", name)
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	user, err := tmpl.New("README").Parse(string(bs))
+	if err != nil {
+		return nil, err
+	}
+	if user.Tree == nil || parse.IsEmptyTree(user.Tree.Root) {
+		// Only redefines blocks of the builtin template.
+		return tmpl, nil
+	}
+	return user, nil
+}
+
+// partialExts are the extensions of the files parsed as partial templates.
+// Other files, such as images referenced by the README, are skipped.
+var partialExts = map[string]bool{".md": true, ".tmpl": true}
+
+// parsePartials parses each *.md or *.tmpl file in dir, if it exists, into a
+// template of tmpl named by the file name without its extension, e.g.
+// "install" for "install.md".
+func parsePartials(tmpl *template.Template, dir string) error {
+	fis, err := ioutil.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, fi := range fis {
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), ".") || !partialExts[filepath.Ext(fi.Name())] {
+			continue
+		}
+		bs, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name()))
+		if _, err := tmpl.New(name).Parse(string(bs)); err != nil {
+			return fmt.Errorf("failed to parse partial: %w", err)
+		}
+	}
+	return nil
+}
@@ -5,12 +5,15 @@
 // Automatically generate a Markdown README for your Go project.
 //
 // This tool creates a GitHub-flavored README.md using the same format as godoc.
-// It includes the package summary and generates badges for pkg.go.dev and
-// Travis CI.
+// It includes the package summary and generates badges for pkg.go.dev and the
+// build status of your CI pipelines.
 //
 // This is a fork of James Frasche's project, found at
 // https://github.com/jimmyfrasche/autoreadme.
 //
+// The generator is also available as a library, so that you can call it from
+// your own tools: see the go.jpap.org/godoc-readme-gen/readmegen package.
+//
 //
 // What It Does
 //
@@ -19,15 +22,143 @@
 // will not be overwritten without the `-f` flag.  You can specify the path to
 // the package directory as the final argument to the tool.
 //
+// Instead of a single directory, you may also provide one or more package
+// patterns, such as `./...`, to generate a README for each matching package.
+// The packages are loaded all at once, which is much faster than running the
+// tool for each package.  If the README for one package fails to generate, the
+// remaining packages are still generated, and the errors are reported at the
+// end.
+//
+// With the `-index` flag, an index of all of the packages is also generated in
+// the package directory, if one is given, or the working directory otherwise.
+// If that directory contains one of the packages, the index is included in its
+// README.  Otherwise, an index README is generated on its own, using the
+// template given by the `-template` flag, or a builtin index template.
+//
 // A template for the README is specified by the `-template` flag, and by
 // default it looks for a file named `.README.template.md` in the package
 // directory.  If the default template is not found, or an alternate is not
-// provided, the default template is used.
+// provided, the default template is used.  Pass `-template ""` to use the
+// default template regardless.
 //
 // To view the default template, pass the `-print-template` function to dump it
 // to stdout.  You might redirect this output to a file so you may use it as the
 // basis for creating your own custom template.
 //
+// Rather than copying the whole default template, you may override just the
+// sections you care about.  Each section of the default template is a named
+// block: "header", "badges", "install", "import", "overview", "packages",
+// "examples", "api", "bugs" and "license".  A template that only redefines some
+// of the blocks, and has no other content, renders the default template with
+// those blocks replaced:
+//
+//   {{define "install"}}# Install
+//
+//   Download a release from our website.
+//
+//   {{end}}
+//
+// A template with content of its own may include the blocks of the default
+// template, such as `{{template "api" .}}`.
+//
+// Partial templates may also be placed in a `.readme` directory of the package,
+// where each `*.md` or `*.tmpl` file defines a template named by the file name,
+// without its extension; other files are ignored.  For example, the file
+// `.readme/install.md` replaces the "install" block, with or without a custom
+// template, and `.readme/footer.md` may be included by a custom template with
+// `{{template "footer" .}}`.
+//
+//
+// Hand-Written READMEs
+//
+// If you would rather write the README yourself, and only generate some of its
+// sections, pass the `-markers` flag.  The tool then finds pairs of markers in
+// the existing README.md, such as:
+//
+//   <!-- godoc-readme-gen:start api -->
+//   <!-- godoc-readme-gen:end api -->
+//
+// and replaces only the text between each pair with the template block named
+// by the start marker, such as "overview" or "api", or one of your own
+// templates or partials.  Everything outside of the markers is left intact, and
+// the README is updated in place without the `-f` flag.  The name of the end
+// marker is optional.
+//
+//
+// Configuration File
+//
+// Rather than passing many flags in each `//go:generate` line, you may place
+// them in a `.godoc-readme-gen.yaml` file.  It is found by searching the
+// package directory, then each of its parents up to the module root, so a
+// single file at the module root can configure every package.  Use the
+// `-config` flag to give another file name, or an empty name to ignore config
+// files.
+//
+// Flags given on the command line take precedence over the config file, even
+// when given empty or zero values, such as `-title ""`.  The config file may
+// override its settings for the packages in given directories, relative to the
+// config file, as follows:
+//
+//   title: My Project
+//   template: .github/README.template.md  # relative to the config file
+//   output: README.md                     # relative to the package directory
+//   badges: [godoc, ci, license]
+//   tocDepth: 2
+//   nestHeadings: true
+//   linkIdentifiers: true
+//   rawMarkdown: true
+//   defs:
+//     owner: Jane Doe
+//   packages:
+//     cmd/tool:
+//       title: My Tool
+//       defs:
+//         owner: John Doe
+//
+// The `defs` map gives template variables, in the same way as the `-def` flag.
+//
+//
+// Doc Comment Syntax
+//
+// Doc comments are parsed using the go/doc/comment package, so the Go 1.19 doc
+// comment syntax is supported: "# Heading" headings, "[text]: URL" link
+// definitions, "[Name]" doc links, and indented "-" and "1." lists.  Implicit
+// headings used by older doc comments continue to be supported.
+//
+// Doc links such as "[Client.Do]" or "[net/http.Handler]" are resolved against
+// the types and imports of the package, and rendered as Markdown links to
+// pkg.go.dev.  Use the `-doc-base-url` flag to link to a private doc server
+// instead.
+//
+// Doc comments that use the Go 1.19 syntax, that is "# Heading" headings,
+// indented lists or link definitions, are rendered so that the README reads as
+// the docs do on pkg.go.dev: Markdown metacharacters in their text, such as
+// "*", "_", "`", "[", "<", "|" and "&", are escaped, as are "+", "-", "#", ">"
+// and "1." at the start of a line, and lines of "=" that would underline a
+// heading, so that nothing is formatted by accident.
+//
+// Older doc comments commonly write Markdown, such as the `code` spans of this
+// one, so Markdown written in them is passed through to the README.  Only the
+// Markdown metacharacters that would format the text by accident are escaped:
+// "*" and "_" within a word, as in "a*b*c" or "HTTP_PROXY", "#" at the start
+// of a line, and "<" before a letter, "/" or "!", as in "<T>".  If your doc
+// comments intentionally write Markdown, pass the `-raw-markdown` flag to pass
+// their text through as-is, without escaping.
+//
+// Bare URLs are written as `<URL>` autolinks, so that every Markdown renderer
+// links them.  With the `-link-identifiers` flag, the exported identifiers of
+// the package, such as "NewDoc" or "Doc.Map", are also linked to their
+// sections of the API of the builtin template, given by the .Anchor of each
+// func and type.
+//
+// Headings of doc comments are rendered as "## Heading", below the "# Overview"
+// of the builtin template.  When a template places the docs elsewhere, such as
+// under a "### Details" heading, pass `-heading-offset 2` to render them as
+// "#### Heading", or use the `shiftHeadings` template function on .Doc.  With
+// the `-nest-headings` flag, the headings of each doc comment, including those
+// of .API, are instead nested one level below whichever heading of the
+// template encloses them, so that the README has a valid outline.
+//
 //
 // Lists and Bullets
 //
@@ -35,12 +166,16 @@
 // turned into lists by Markdown.  Paragraphs between list items are
 // automatically indented so that they appear as part of the same list item.
 // Similarly for bullets, that are paragraphs that start with the text "* ".
+// Paragraphs numbered hierarchically, such as "1.1. " and "1.1.1. ", are
+// nested within the preceding items.
 //
 // We assume the list and/or bullets continue until the end of the text section
 // (that is, until the next heading or end of document).  But sometimes you may
 // wish to "terminate" a list/bullet before then: to do this, insert a pseudo
 // heading "..." before the next paragraph.  The ellipses will not be inserted
-// into the README file.
+// into the README file.  A "..." closes every open item, including nested
+// ones, and a paragraph starting a new "1. " or "* " list also closes the
+// previous list.
 //
 // The following example illustrates this concept:
 //
@@ -59,6 +194,18 @@
 //   // This trailing paragraph is not indented, as it is not considered to be
 //   // part of the above list.
 //
+// Lists in the Go 1.19 syntax, that are indented in the doc comment, may be
+// nested by indenting their items further, as in Markdown.  A numbered list
+// may also contain bulleted items and vice versa, and items numbered
+// hierarchically, as above:
+//
+//   //  1. Fruit
+//   //     - Apple
+//   //     - Pear
+//   //  2. Vegetables
+//   //     2.1. Carrot
+//   //     2.2. Potato
+//
 //
 // Automating README Generation
 //
@@ -69,7 +216,9 @@
 //
 // If you have one or more sub-packages in your project, you can add similar
 // `//go:generate` lines to each, and then regenerate all of the READMEs by
-// running `go generate ./...` from the top-level directory.
+// running `go generate ./...` from the top-level directory.  For projects with
+// many packages, it is faster to add a single `//go:generate` line to the
+// top-level package, that passes the `./...` pattern to the tool.
 //
 // We recommend placing your high-level godoc comments in a separate project
 // file `ϟdocϟ.go` and a `//go:generate` line beneath the `package` declaration
@@ -87,7 +236,10 @@
 // build without error (so it can parse the source code).  If other generators
 // have not yet run, or require regeneration (e.g. out-of-date `stringer`
 // files), your source code might not "compile" and `godoc-readme-gen` will
-// fail, stopping `go generate` from running the other generators.
+// fail, stopping `go generate` from running the other generators.  If you
+// would rather generate the README regardless, pass the `-tolerate-errors`
+// flag: errors are then reported as warnings, and the README is rendered from
+// whatever source code could be parsed.
 //
 // Unfortunately Go source filenames are restricted to being ASCII or Unicode
 // letters, and limited to ASCII punctuation when using modules.  The allowed
@@ -96,6 +248,18 @@
 // ASCII letters.  We choose the ancient Greek letter koppa "ϟ" for this
 // purpose, because it "compares after" all Greek characters too!
 //
+// To make sure that nobody forgets to regenerate the README, run the tool with
+// the `-check` flag in your CI pipeline.  It renders the README in memory,
+// compares it with the existing README.md, and prints a unified diff and fails
+// if they differ, without writing any files.
+//
+// The README is written to README.md in the package directory, unless the `-o`
+// flag names another file, relative to the package directory, such as
+// `docs/README.md`; missing directories are created.  The special name `-`
+// writes the README to standard output instead.  Files are written atomically,
+// via a temporary file that is renamed into place, so that a README is never
+// left half-written, and its permissions are kept.
+//
 //
 // Examples
 //
@@ -112,6 +276,30 @@
 // Generate using a custom template:
 //  godoc-readme-gen -template path/to/my/readme.template.md
 //
+// Fail if the README.md in the current directory is out of date:
+//  godoc-readme-gen -check
+//
+// Overwrite the README.md of every package in the current module:
+//  godoc-readme-gen -f ./...
+//
+// As above, also generating an index of every package in the current directory:
+//  godoc-readme-gen -f -index ./...
+//
+// Define the .Maintainers template variable as the list in a YAML file:
+//  godoc-readme-gen -f -def-file maintainers=maintainers.yaml
+//
+// Render the pkg.go.dev, Go Report Card and license badges, in that order:
+//  godoc-readme-gen -f -badges godoc,goreportcard,license
+//
+// Print the README of the package in the current directory, without writing it:
+//  godoc-readme-gen -o -
+//
+// List two levels of headings in the .TOC table of contents:
+//  godoc-readme-gen -f -toc-depth 2
+//
+// Pass the Markdown written in doc comments through to the README as-is:
+//  godoc-readme-gen -f -raw-markdown
+//
 //
 // Template Variables
 //
@@ -131,6 +319,32 @@
 // the import github.com/golang/go is represented as "golang/go".  This is
 // typically the path within the repo of the package.
 //
+// `.Module` The Go module containing the package, or nil if not in a module.
+// It has the following fields:
+//   .Path       Module path
+//   .Version    Module version, or "" for the main module
+//   .Dir        Directory holding the module files
+//   .GoVersion  Minimum Go version, from the go directive of go.mod
+//   .Requires   Requirements from go.mod, each with .Path, .Version and .Indirect
+//
+// `.RelPath` The path of the package relative to the module root, or "" for the
+// module root.  For example, the package go.jpap.org/godoc-readme-gen/readmegen
+// has the relative path "readmegen".
+//
+// `.Repo` The source repository of the package, or nil if not detected.  It is
+// detected without network access from the "origin" remote of the local git
+// working tree, else from the import comment or import path of the package
+// when hosted on a known forge.  It has the following fields:
+//   .Host           Host name, e.g. "github.com"
+//   .Forge          "github", "gitlab", "bitbucket" or "gitea", or "" if unknown
+//   .Owner          Owner of the repository, including any GitLab subgroups
+//   .Name           Name of the repository
+//   .DefaultBranch  Default branch, if known
+//   .WebURL         Web page of the repository
+//   .Dir            Root directory of the local working tree, if any
+// Use `.Repo.FileURL "path"` for the web page of a file in the repository, on
+// the default branch.
+//
 // `.Bugs` A []string of all bugs as per godoc.
 //
 // `.Commands` A []string of import paths of all main packages.  In addition to
@@ -141,15 +355,113 @@
 //
 // `.Today` The current date in YYYY.MM.DD format.
 //
-// `.Travis` True if there is a `.travis.yml` file in the package directory.
-//
-// `.Examples` a map of Example with all examples from `*_test.go` files. These
-// can be used to include selective examples into the README.  The Example
-// struct has the following fields:
+// `.Travis` True if there is a `.travis.yml` file, as per .CI.
+//
+// `.CI` A []CI of the CI pipelines configured in the package directory, or in
+// the root directory of its module or repository.  GitHub Actions workflows
+// (each file in `.github/workflows`), GitLab CI, CircleCI, Drone, Buildkite and
+// Travis CI are detected.  The builtin template renders a build status badge
+// for each.  The CI struct has the following fields:
+//   .System    "github-actions", "gitlab-ci", "circleci", "drone", "buildkite" or "travis"
+//   .Name      Name of the workflow, or of the CI system
+//   .BadgeURL  Image of the build status badge, or "" if unknown
+//   .LinkURL   Web page of the build status, or "" if unknown
+// Badges require the repository, as per .Repo, to be detected.
+//
+// `.Licenses` A []License of the license files found in the package directory,
+// else in the module root.  Files named LICENSE, LICENCE, COPYING or
+// UNLICENSE, including those with a suffix such as LICENSE.txt or
+// LICENSE-golang, are classified without network access by matching them
+// against a bundled set of license texts.  The builtin template lists them in
+// a "License" section.  The License struct has the following fields:
+//   .SPDX        SPDX license identifier, e.g. "MIT", or "" if unknown
+//   .Path        Path of the license file, relative to the README
+//   .Confidence  Fraction of the file that matches the license text, 0 to 1
+// A file holding more than one license has an entry for each license.
+//
+// `.Badges` A []Badge of the badges given by the `-badges` flag, in order,
+// that the builtin template renders beside the title.  The available badges
+// are "godoc", "goreportcard", "ci" (a badge for each of .CI), "license" (as
+// per .Licenses), "coverage" (Codecov), "release" (the latest tag) and
+// "goversion" (from go.mod), and by default "godoc,ci" are rendered.  Badges
+// that do not apply to the package, such as those requiring a repository on an
+// unsupported forge, are omitted.  The Badge struct has the fields .Name,
+// .Alt, .Image and .Link, and `.Markdown` renders it.  The `badges` template
+// function renders the Markdown of .Badges, or of the badges given by name,
+// e.g. `{{badges "godoc" "license"}}`.
+//
+// `.TOC` A table of contents of the README: a nested list of links to its
+// headings, including those of .Doc, with GitHub-compatible anchors.  Headings
+// within code blocks are ignored, and repeated headings get anchors suffixed by
+// "-1", "-2", etc.  The `-toc-depth` flag limits the number of levels listed,
+// including the top level.  The `toc` template function renders the same
+// list, taking an optional depth, e.g. `{{toc 2}}`.
+//
+// `.Examples` a map of Example with all examples from `*_test.go` files,
+// including those of an external `_test` package, keyed by the example name.
+// For example, `ExampleT_M_suffix` is keyed by "T_M_suffix".  These can be used
+// to include selective examples into the README.  The Example struct has the
+// following fields:
 //   .Name    Name of the example
+//   .Symbol  Symbol exemplified, e.g. "T" or "T.M", or "" for the package
+//   .Suffix  Example name suffix, if any
 //   .Code    Rendered example code similar to godoc
 //   .Output  Example output, if any
 //
+// `.PackageExamples` A []Example of the package-level examples, that are not
+// associated with any particular symbol.  Examples of a symbol are found in
+// the .Examples field of its .API entry.
+//
+// `.Packages` A []Package of the packages in the index, when the `-index` flag
+// is given, sorted by import path.  The Package struct has the following
+// fields:
+//   .Name        Package name
+//   .ImportPath  Package import path
+//   .Synopsis    The first sentence of the package documentation
+//   .IsLibrary   True if the package is not a main package
+//   .Commands    Import paths of all main packages, as per .Commands
+//   .Readme      Relative path of the package README from the index README
+//
+// `.API` The exported API of the package, in the same order as godoc.  The
+// builtin template renders it in an "API" section, similar to pkg.go.dev.  It
+// has the following fields:
+//   .Consts  Exported constant declarations
+//   .Vars    Exported variable declarations
+//   .Funcs   Exported functions not associated with a type
+//   .Types   Exported types
+// Each of .Consts and .Vars have the fields .Names, .Decl (the formatted
+// declaration) and .Doc (the Markdown documentation).  Each of .Funcs have the
+// fields .Name, .Recv (the receiver type of a method), .Decl, .Doc, .Anchor
+// (of its heading in the builtin template) and .Examples.  Each of .Types have
+// the fields .Name, .Decl, .Doc, .Anchor, .Examples, along with the .Consts,
+// .Vars, .Funcs (constructors) and .Methods associated with the type.  Use
+// `.API.Empty` to test if there is no exported API.
+//
+// Additional variables may be given with the `-def name=value` flag, or the
+// `defs` map of the config file.  Each is available with both a lowercase and
+// uppercase first character, e.g. `.name` and `.Name`, as are the keys of
+// nested maps.  A dotted name, such as `-def links.docs=URL`, sets a key of a
+// nested map, e.g. `.Links.Docs`, keeping the other keys of the map given by
+// the config file.  To give structured data, such as a list of maintainers to
+// `range` over, use `-def-json name=JSON`, or `-def-file name=path` for a JSON
+// or YAML file.
+//
+//
+// Template Functions
+//
+// In addition to the builtin functions of text/template, templates may use
+// functions for strings (such as `lower`, `join`, `indent` and `trim`), lists,
+// dates, and Markdown: `mdEscape` escapes text, `codeFence` wraps code in a
+// fenced code block, `shiftHeadings` changes the level of the headings of
+// Markdown such as .Doc, `toc` lists the headings of the README or of other
+// Markdown, and `include`
+// inserts a file from the package directory.  The functions are documented in
+// a comment at the top of the `-print-template` output.  For example:
+//
+//   ## Overview
+//
+//   {{.Doc | shiftHeadings 1}}
+//
 package main
 
 //go:generate godoc-readme-gen -f -title "GoDoc README Markdown Generator"